@@ -0,0 +1,456 @@
+package conjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+// Codec is a reusable JSON handling configuration, providing a single entry
+// point for marshaling, unmarshaling, encoding, and decoding JSON with the same
+// transformations.
+//
+// A Codec is immutable once built, and is safe for concurrent use by multiple
+// goroutines.
+type Codec struct {
+	transformers []transform.ContextTransformer
+	types        map[reflect.Type][]transform.ContextTransformer
+
+	disallowUnknownFields bool
+	unknownFieldHandler   func(path string)
+	exactFieldNames       bool
+	opaqueMarshalers      bool
+
+	// searches caches the searches of types for nested values that have
+	// their own transformations, by type
+	searches sync.Map // map[reflect.Type]subtreeSearch
+
+	// probes caches the keys probed against the transformers of the codec
+	// and of the types with their own convention, by probe
+	probes sync.Map // map[probe]string
+}
+
+// Option is a structure defining a configuration of a Codec as it's built.
+//
+// Options are only applied by NewCodec, so that a built Codec is never
+// reconfigured, and may be safely shared.
+type Option struct {
+	apply func(*Codec)
+}
+
+// NewCodec takes a variable number of `Option`s and returns a new Codec built
+// by applying each of the given options, in order.
+func NewCodec(options ...Option) *Codec {
+	codec := &Codec{}
+
+	for _, option := range options {
+		if nil != option.apply {
+			option.apply(codec)
+		}
+	}
+
+	return codec
+}
+
+// newCodec takes a list of `transform.ContextTransformer`s and returns a new
+// Codec that runs them, without copying the given list.
+func newCodec(transformers []transform.ContextTransformer) *Codec {
+	return &Codec{transformers: transformers}
+}
+
+// WithTransformers takes a variable number of `transform.Transformer`s and
+// returns an Option that adds the given transformers to a Codec's list of
+// transformers.
+func WithTransformers(transformers ...transform.Transformer) Option {
+	return WithContextTransformers(ignoreContext(transformers)...)
+}
+
+// WithContextTransformers takes a variable number of
+// `transform.ContextTransformer`s and returns an Option that adds the given
+// transformers to a Codec's list of transformers.
+func WithContextTransformers(transformers ...transform.ContextTransformer) Option {
+	return Option{func(c *Codec) {
+		// Always build a new list, so that a Codec never shares its list
+		c.transformers = append(
+			append([]transform.ContextTransformer(nil), c.transformers...),
+			transformers...,
+		)
+	}}
+}
+
+// WithTypeTransformers takes a variable number of `transform.Transformer`s and
+// returns an Option that registers the given transformers as the convention
+// of the type T (and of pointers to T) in a Codec's type registry.
+//
+// When a value of a registered type appears within a marshaled or unmarshaled
+// value, the keys of the JSON subtree of that nested value are decided by the
+// transformers registered for its type, instead of by the Codec's list of
+// transformers (or those of any enclosing registered type). The Codec's
+// transformers still see the entire subtree, with its keys kept as they are,
+// so that transformers of values, such as transform.Redact, apply within it
+// too. Registering a type again replaces its previously registered
+// transformers.
+//
+// When marshaling, the JSON of the nested values is spliced into the document
+// before the Codec's transformers run, so that the transformers that format
+// the JSON document, such as transform.Canonical, transform.Compact, and
+// transform.Indent, format the entire document the same, however they're
+// wrapped.
+//
+// Values within an `interface{}` are matched by their dynamic type when
+// marshaling, but are never matched when unmarshaling, as their Go type isn't
+// known until after they're decoded.
+func WithTypeTransformers[T any](transformers ...transform.Transformer) Option {
+	return WithTypeContextTransformers[T](ignoreContext(transformers)...)
+}
+
+// WithTypeContextTransformers takes a variable number of
+// `transform.ContextTransformer`s and returns an Option that registers the
+// given transformers as the convention of the type T in a Codec's type
+// registry.
+//
+// See the documentation for WithTypeTransformers for more details.
+func WithTypeContextTransformers[T any](transformers ...transform.ContextTransformer) Option {
+	return Option{func(c *Codec) {
+		// Always build a new registry, so that a Codec never shares its
+		// registry
+		types := make(map[reflect.Type][]transform.ContextTransformer, len(c.types)+1)
+
+		for typ, typeTransformers := range c.types {
+			types[typ] = typeTransformers
+		}
+
+		types[reflect.TypeFor[T]()] = append([]transform.ContextTransformer(nil), transformers...)
+		c.types = types
+	}}
+}
+
+// DisallowUnknownFields returns an Option that makes a Codec reject, when
+// unmarshaling or decoding, any JSON object key that doesn't map to a field of
+// the struct that the object is decoded into, once the key is transformed.
+//
+// Rather than only reporting the first unknown key in its transformed form,
+// as `encoding/json.Decoder.DisallowUnknownFields` would, every unknown key is
+// reported by its JSON path in the original data, in an
+// `*UnknownFieldsError`. When any unknown key is found, the value isn't
+// decoded into at all.
+//
+// Keys are checked in the transformed data, which is what's decoded, so
+// transformers that change the data's structure, such as transform.Envelope or
+// transform.Flatten, are supported. Paths within any part of the data whose
+// structure was changed use the transformed keys instead.
+func DisallowUnknownFields() Option {
+	return Option{func(c *Codec) {
+		c.disallowUnknownFields = true
+	}}
+}
+
+// WithUnknownFieldHandler takes a function and returns an Option that makes a
+// Codec call the function, when unmarshaling or decoding, with the JSON path
+// of each JSON object key that doesn't map to a field of the struct that the
+// object is decoded into, once the key is transformed.
+//
+// Unlike with DisallowUnknownFields, the unknown keys are only reported, such
+// as to log a warning, and the value is still decoded into. Paths use the keys
+// of the original data, joined with dots, and the indexes of array elements,
+// as described in the documentation for DisallowUnknownFields.
+func WithUnknownFieldHandler(handler func(path string)) Option {
+	return Option{func(c *Codec) {
+		c.unknownFieldHandler = handler
+	}}
+}
+
+// ExactFieldNames returns an Option that makes a Codec match JSON object keys
+// to struct fields exactly, when unmarshaling or decoding, rather than
+// case-insensitively as `encoding/json` does.
+//
+// Each field's expected key is the key that the field is marshaled as, once
+// the transformers that apply to the field have run on its name. The name is
+// followed through transformers that move it, such as transform.Envelope, and
+// transformers that would drop it, such as transform.Redact, don't decide it.
+// A key that differs from the expected key of every field in any way, such as
+// "imageUrl" when "imageURL" is expected, doesn't map to any field and is
+// therefore an unknown field, which is rejected or reported as configured with
+// DisallowUnknownFields or WithUnknownFieldHandler. Either way, the value of
+// such a key is never decoded into any field.
+//
+// To derive the expected keys, the transformers are run on documents that
+// contain only a field's name, such as `{"ImageURL":true}`, in addition to the
+// decoded data. The derived keys are cached by the Codec, so that the
+// transformers see such a document at most once for each name and convention,
+// within the context of the first operation that needs it.
+func ExactFieldNames() Option {
+	return Option{func(c *Codec) {
+		c.exactFieldNames = true
+	}}
+}
+
+// OpaqueMarshalers returns an Option that makes a Codec treat the JSON of
+// values that encode or decode their own JSON as opaque, leaving it untouched
+// by any transformers.
+//
+// When marshaling or encoding, the JSON of values of types that implement
+// `encoding/json.Marshaler`, such as `encoding/json.RawMessage`, isn't
+// transformed. When unmarshaling or decoding, the JSON of values to be decoded
+// into types that implement `encoding/json.Unmarshaler` isn't transformed.
+// Types with their own convention, registered with WithTypeTransformers or
+// declared as a ConventionProvider, are still transformed with it.
+func OpaqueMarshalers() Option {
+	return Option{func(c *Codec) {
+		c.opaqueMarshalers = true
+	}}
+}
+
+// NewMarshaler takes a value and returns an `encoding/json.Marshaler` that
+// runs the codec's transformers upon JSON marshaling.
+//
+// See the documentation for the package-level NewMarshaler for more details.
+func (c *Codec) NewMarshaler(value interface{}) json.Marshaler {
+	return &marshaler{context.Background(), value, c, nil}
+}
+
+// NewUnmarshaler takes a pointer value and returns an
+// `encoding/json.Unmarshaler` that runs the codec's transformers upon JSON
+// unmarshaling.
+//
+// See the documentation for the package-level NewUnmarshaler for more details.
+func (c *Codec) NewUnmarshaler(value interface{}) json.Unmarshaler {
+	return &unmarshaler{context.Background(), value, c, tracker{}}
+}
+
+// NewEncoder takes an `encoding/json.Encoder` and returns a `ContextEncoder`
+// that runs the codec's transformers upon JSON encoding.
+//
+// See the documentation for the package-level NewEncoder for more details.
+func (c *Codec) NewEncoder(inner *json.Encoder) ContextEncoder {
+	return &encoder{inner, c}
+}
+
+// NewDecoder takes an `encoding/json.Decoder` and returns an `ArrayDecoder`
+// that runs the codec's transformers upon JSON decoding.
+//
+// See the documentation for the package-level NewArrayDecoder for more
+// details.
+func (c *Codec) NewDecoder(inner *json.Decoder) ArrayDecoder {
+	return &decoder{inner, c}
+}
+
+// NewStreamEncoder takes an `io.Writer` and returns a `StreamEncoder` that
+// runs the codec's transformers upon JSON encoding.
+//
+// See the documentation for the package-level NewStreamEncoder for more
+// details.
+func (c *Codec) NewStreamEncoder(writer io.Writer) StreamEncoder {
+	return &streamEncoder{writer, c}
+}
+
+// Marshal takes a value and returns the JSON encoding of the value, with the
+// codec's transformers having run on the output.
+//
+// The output is returned exactly as the transformers produced it, rather than
+// compacted and HTML-escaped as `encoding/json` does to the output of an
+// `encoding/json.Marshaler`, so that formatting transformers such as
+// transform.Indent take effect.
+//
+// See the documentation for `encoding/json.Marshal` for more details.
+func (c *Codec) Marshal(value interface{}) ([]byte, error) {
+	return c.MarshalContext(context.Background(), value)
+}
+
+// MarshalIndent takes a value, a prefix, and an indent and behaves like
+// Marshal, but applies the given prefix and indent to format the output.
+//
+// The output of Marshal is indented as-is, so that it's escaped the same way
+// as the output of Marshal, rather than HTML-escaped again as by
+// `encoding/json.MarshalIndent`.
+//
+// See the documentation for `encoding/json.MarshalIndent` for more details.
+func (c *Codec) MarshalIndent(value interface{}, prefix, indent string) ([]byte, error) {
+	data, err := c.Marshal(value)
+
+	if nil != err {
+		return nil, err
+	}
+
+	var indented bytes.Buffer
+
+	if err := json.Indent(&indented, data, prefix, indent); nil != err {
+		return nil, err
+	}
+
+	return indented.Bytes(), nil
+}
+
+// MarshalContext takes a context and a value and behaves like Marshal, running
+// the codec's transformers within the given context.
+//
+// See the documentation for the package-level MarshalContext for more details.
+func (c *Codec) MarshalContext(ctx context.Context, value interface{}) ([]byte, error) {
+	if err := ctx.Err(); nil != err {
+		return nil, err
+	}
+
+	return marshalJSON(&marshaler{ctx, value, c, nil})
+}
+
+// Unmarshal takes JSON encoded data and a pointer value and stores the result
+// of decoding the data in the pointed to value, with the codec's transformers
+// having run on the input.
+//
+// See the documentation for `encoding/json.Unmarshal` for more details.
+func (c *Codec) Unmarshal(data []byte, value interface{}) error {
+	return c.UnmarshalContext(context.Background(), data, value)
+}
+
+// UnmarshalContext takes a context, JSON encoded data, and a pointer value and
+// behaves like Unmarshal, running the codec's transformers within the given
+// context.
+//
+// See the documentation for the package-level UnmarshalContext for more
+// details.
+func (c *Codec) UnmarshalContext(ctx context.Context, data []byte, value interface{}) error {
+	if err := ctx.Err(); nil != err {
+		return err
+	}
+
+	return json.Unmarshal(data, &unmarshaler{ctx, value, c, tracker{}})
+}
+
+// UnmarshalPresence takes JSON encoded data and a pointer value and behaves
+// like Unmarshal, but also returns the presence of the fields of the decoded
+// structs in the data.
+//
+// See the documentation for the package-level UnmarshalPresence for more
+// details.
+func (c *Codec) UnmarshalPresence(data []byte, value interface{}) (Presence, error) {
+	presence := Presence{}
+
+	if err := json.Unmarshal(data, &unmarshaler{context.Background(), value, c, tracker{presence: presence}}); nil != err {
+		return nil, err
+	}
+
+	return presence, nil
+}
+
+// conventionCache caches the transformers declared by ConventionProvider
+// types, by type, so that each type's JSONTransformers is only called once.
+var conventionCache sync.Map // map[reflect.Type][]transform.ContextTransformer
+
+// typeTransformers takes a type and returns the transformers of the type's own
+// convention, either registered for the type or declared by the type as a
+// ConventionProvider, and whether the type has its own convention.
+func (c *Codec) typeTransformers(t reflect.Type) ([]transform.ContextTransformer, bool) {
+	if transformers, isRegistered := c.types[t]; isRegistered {
+		return transformers, true
+	}
+
+	if cached, isCached := conventionCache.Load(t); isCached {
+		transformers := cached.([]transform.ContextTransformer)
+
+		return transformers, nil != transformers
+	}
+
+	var transformers []transform.ContextTransformer
+
+	// Pointers defer to the type that they point to, as a method with a value
+	// receiver can't be called with a nil pointer
+	if t.Kind() != reflect.Pointer && implements(t, conventionProviderType) {
+		provider := reflect.New(t).Interface().(ConventionProvider)
+
+		// The list is never nil, unlike that of a type without a convention
+		transformers = ignoreContext(provider.JSONTransformers())
+	}
+
+	cached, _ := conventionCache.LoadOrStore(t, transformers)
+	transformers = cached.([]transform.ContextTransformer)
+
+	return transformers, nil != transformers
+}
+
+// checksFields returns whether the codec checks the keys of decoded JSON
+// objects against the fields of the structs that they're decoded into, in
+// which case the JSON must always be walked when unmarshaling.
+func (c *Codec) checksFields() bool {
+	return c.disallowUnknownFields || nil != c.unknownFieldHandler || c.exactFieldNames
+}
+
+// marshal takes a context, a value, and optional key spellings and returns the
+// transformed JSON encoding of the value, with the keys of the fields and map
+// entries that have a recorded spelling respelled as recorded, and with the
+// keys of the members of any Extras fields emitted untransformed.
+func (c *Codec) marshal(ctx context.Context, value interface{}, spellings KeySpellings) ([]byte, error) {
+	data, err := json.Marshal(value)
+
+	if nil != err {
+		return nil, err
+	}
+
+	// Values without any nested subtrees or spellings are transformed whole,
+	// without being walked
+	if 0 == len(spellings) && !c.valueHasSubtrees(reflect.ValueOf(value)) {
+		return transformBytes(ctx, data, transform.Marshal, c.transformers), nil
+	}
+
+	w := &walker{ctx: ctx, codec: c, data: data, direction: transform.Marshal}
+	w.spellings = spellings
+	root := w.root()
+
+	if w.parse(root) {
+		w.walkValue(reflect.ValueOf(value), root.node, root)
+	}
+
+	return root.transform(w), nil
+}
+
+// unmarshal takes a context, JSON encoded data, a pointer value, and a tracker,
+// and stores the result of decoding the transformed data in the pointed to
+// value, recording what the tracker tracks about the decoded fields, and
+// capturing the members that don't map to any field into any Extras fields.
+//
+// Errors describing the position of a value that couldn't be decoded are
+// translated to describe the position and the path of the value in the
+// original data, so that the original key names are reported.
+func (c *Codec) unmarshal(ctx context.Context, data []byte, value interface{}, tracker tracker) error {
+	w := &walker{ctx: ctx, codec: c, data: data, direction: transform.Unmarshal, tracker: tracker}
+	t := reflect.TypeOf(value)
+
+	var transformed []byte
+
+	// Values without any nested subtrees are transformed whole, without being
+	// walked, unless their fields are tracked or checked
+	if !tracker.isTracking() && !c.checksFields() && !c.hasSubtrees(t) {
+		transformed = transformBytes(ctx, data, transform.Unmarshal, c.transformers)
+	} else {
+		traced := w.run(newTrace(data), c.transformers, nil)
+		transformed = traced.data
+
+		if nil != traced.root && nil != t {
+			if decoded := w.decode(t, traced.root, traced, w.root()); nil != decoded {
+				transformed = decoded
+			}
+		}
+	}
+
+	if 0 < len(w.unknownFields) {
+		if nil != c.unknownFieldHandler {
+			for _, path := range w.unknownFields {
+				c.unknownFieldHandler(path)
+			}
+		}
+
+		if c.disallowUnknownFields {
+			return &UnknownFieldsError{Paths: w.unknownFields}
+		}
+	}
+
+	if err := json.Unmarshal(transformed, value); nil != err {
+		return translateError(err, data, transformed)
+	}
+
+	return nil
+}
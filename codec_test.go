@@ -0,0 +1,728 @@
+package conjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+type codecModel struct {
+	ImageURL string
+	IsActive bool
+}
+
+const (
+	codecModelConventionalJSON = `{"image_url":"https://example.com/image.png","is_active":true}`
+)
+
+var testCodecModel = codecModel{"https://example.com/image.png", true}
+
+func codecOutput(codec *Codec) string {
+	return codecOutputOf(codec, testCodecModel)
+}
+
+func codecOutputOf(codec *Codec, value interface{}) string {
+	output, _ := codec.Marshal(value)
+
+	return string(output)
+}
+
+func TestNewCodec(t *testing.T) {
+	if codec := NewCodec(); 0 != len(codec.transformers) {
+		t.Errorf("NewCodec had `%d` transformers, when expected to have `0`", len(codec.transformers))
+	}
+
+	var order []int
+	orderedTransformer := func(i int) transform.Transformer {
+		return func(data []byte, direction transform.Direction) []byte {
+			order = append(order, i)
+
+			return data
+		}
+	}
+
+	codec := NewCodec(
+		WithTransformers(orderedTransformer(0), orderedTransformer(1)),
+		WithContextTransformers(transform.IgnoreContext(orderedTransformer(2))),
+		WithTransformers(orderedTransformer(3)),
+	)
+
+	if _, err := codec.Marshal(true); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expected := []int{0, 1, 2, 3}; len(expected) != len(order) {
+		t.Errorf("order was `%v`, when expected to be `%v`", order, expected)
+	} else {
+		for i := range expected {
+			if expected[i] != order[i] {
+				t.Errorf("order was `%v`, when expected to be `%v`", order, expected)
+			}
+		}
+	}
+}
+
+// TestNewCodec_AssertOptionsDontShareState tests that codecs built from the
+// same options don't share, and therefore can't modify, each other's state
+func TestNewCodec_AssertOptionsDontShareState(t *testing.T) {
+	base := WithTransformers(noOpTransformer, noOpTransformer)
+
+	first := NewCodec(base, WithTransformers(transform.ConventionalKeys()))
+	second := NewCodec(base, WithTransformers(transform.CamelCaseKeys(false)))
+
+	if output := codecOutput(first); codecModelConventionalJSON != output {
+		t.Errorf("Output %s doesn't match expected %s", output, codecModelConventionalJSON)
+	}
+
+	if expected, output := `{"imageURL":"https://example.com/image.png","isActive":true}`, codecOutput(second); expected != output {
+		t.Errorf("Output %s doesn't match expected %s", output, expected)
+	}
+}
+
+func TestNewCodec_AssertZeroOptionIsIgnored(t *testing.T) {
+	codec := NewCodec(Option{}, WithTransformers(transform.ConventionalKeys()), Option{})
+
+	if output := codecOutput(codec); codecModelConventionalJSON != output {
+		t.Errorf("Output %s doesn't match expected %s", output, codecModelConventionalJSON)
+	}
+}
+
+func TestCodec_Marshal(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	if output, err := codec.Marshal(testCodecModel); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if codecModelConventionalJSON != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, codecModelConventionalJSON)
+		}
+	}
+
+	shouldError := errorMarshaler(true)
+	if _, err := codec.Marshal(&shouldError); nil == err {
+		t.Error("Expected error was nil")
+	}
+}
+
+func TestCodec_Marshal_AssertOutputIsntReformatted(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys(), transform.Indent("", "  ")))
+
+	var expected bytes.Buffer
+	json.Indent(&expected, []byte(codecModelConventionalJSON), "", "  ")
+
+	if output, err := codec.Marshal(testCodecModel); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected.String() != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, expected.String())
+		}
+	}
+
+	if output, _ := Marshal(map[string]string{"a": "<b>"}, transform.Canonical()); `{"a":"<b>"}` != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, `{"a":"<b>"}`)
+	}
+
+	invalid := NewCodec(WithTransformers(func(data []byte, direction transform.Direction) []byte {
+		return data[1:]
+	}))
+
+	if _, err := invalid.Marshal(testCodecModel); true {
+		if _, isMarshalerError := err.(*json.MarshalerError); !isMarshalerError {
+			t.Errorf("Error (%T) %q isn't a *json.MarshalerError", err, err)
+		}
+	}
+}
+
+func TestCodec_MarshalIndent(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	var expected bytes.Buffer
+	json.Indent(&expected, []byte(codecModelConventionalJSON), ">", "\t")
+
+	if output, err := codec.MarshalIndent(testCodecModel, ">", "\t"); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected.String() != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, expected.String())
+		}
+	}
+}
+
+func TestCodec_MarshalIndent_AssertOutputIsEscapedLikeMarshal(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys(), transform.Canonical()))
+	value := map[string]string{"htmlBody": "<b>"}
+
+	marshaled, _ := codec.Marshal(value)
+
+	if output, err := codec.MarshalIndent(value, "", ""); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "{\n\"html_body\": \"<b>\"\n}"; expected != string(output) {
+			t.Errorf("Output %q doesn't match expected %q", output, expected)
+		}
+
+		if expected := `{"html_body":"<b>"}`; expected != string(marshaled) {
+			t.Errorf("Output %s doesn't match expected %s", marshaled, expected)
+		}
+	}
+}
+
+func TestCodec_MarshalContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "context value")
+	directionRan := transform.Unmarshal
+
+	codec := NewCodec(WithContextTransformers(contextTransformer(&directionRan)))
+
+	if output, err := codec.MarshalContext(ctx, testCodecModel); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := `"context value"`; expected != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, expected)
+		}
+
+		if transform.Marshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Marshal")
+		}
+	}
+}
+
+func TestCodec_Unmarshal(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	var model codecModel
+	if err := codec.Unmarshal([]byte(codecModelConventionalJSON), &model); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if testCodecModel != model {
+			t.Errorf("model was `%v`, when expected to be `%v`", model, testCodecModel)
+		}
+	}
+
+	if err := codec.Unmarshal([]byte(codecModelConventionalJSON), model); nil == err {
+		t.Error("Expected error was nil")
+	}
+}
+
+func TestCodec_UnmarshalContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "context value")
+	directionRan := transform.Marshal
+
+	codec := NewCodec(WithContextTransformers(contextTransformer(&directionRan)))
+
+	var val string
+	if err := codec.UnmarshalContext(ctx, []byte(`"input value"`), &val); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "context value"; expected != val {
+			t.Errorf("val was %q, when expected to be %q", val, expected)
+		}
+
+		if transform.Unmarshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Unmarshal")
+		}
+	}
+}
+
+func TestCodec_NewMarshaler(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	if output, err := json.Marshal(codec.NewMarshaler(testCodecModel)); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if codecModelConventionalJSON != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, codecModelConventionalJSON)
+		}
+	}
+}
+
+func TestCodec_NewUnmarshaler(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	var model codecModel
+	if err := json.Unmarshal([]byte(codecModelConventionalJSON), codec.NewUnmarshaler(&model)); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if testCodecModel != model {
+			t.Errorf("model was `%v`, when expected to be `%v`", model, testCodecModel)
+		}
+	}
+}
+
+func TestCodec_NewEncoder(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(json.NewEncoder(&buf)).Encode(testCodecModel); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := codecModelConventionalJSON + "\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+	}
+}
+
+func TestCodec_NewDecoder(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	var model codecModel
+	buf := bytes.NewBufferString(codecModelConventionalJSON)
+	if err := codec.NewDecoder(json.NewDecoder(buf)).Decode(&model); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if testCodecModel != model {
+			t.Errorf("model was `%v`, when expected to be `%v`", model, testCodecModel)
+		}
+	}
+}
+
+func TestCodec_NewStreamEncoder(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	var buf bytes.Buffer
+	if err := codec.NewStreamEncoder(&buf).EncodeSeq(context.Background(), func(yield func(interface{}) bool) {
+		_ = yield(testCodecModel) && yield(testCodecModel)
+	}); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "[" + codecModelConventionalJSON + "," + codecModelConventionalJSON + "]\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+	}
+}
+
+// TestCodec_AssertConcurrentUseIsSafe tests that a single codec may be shared
+// across goroutines (most useful when run with the race detector)
+func TestCodec_AssertConcurrentUseIsSafe(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var model codecModel
+			output, _ := codec.Marshal(testCodecModel)
+
+			if err := codec.Unmarshal(output, &model); nil != err || testCodecModel != model {
+				t.Errorf("model was `%v` (%v), when expected to be `%v`", model, err, testCodecModel)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+type vendorModel struct {
+	AccountID   string
+	DisplayName string
+}
+
+type domainModel struct {
+	OwnerName string
+	Vendor    vendorModel
+	Vendors   []*vendorModel
+	ByKey     map[string]vendorModel
+	Dynamic   interface{}
+}
+
+func TestWithTypeTransformers(t *testing.T) {
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+	)
+
+	model := domainModel{
+		OwnerName: "owner",
+		Vendor:    vendorModel{"a", "A"},
+		Vendors:   []*vendorModel{{"b", "B"}, nil},
+		ByKey:     map[string]vendorModel{"someKey": {"c", "C"}},
+		Dynamic:   vendorModel{"d", "D"},
+	}
+
+	const expectedJSON = `{"owner_name":"owner",` +
+		`"vendor":{"accountID":"a","displayName":"A"},` +
+		`"vendors":[{"accountID":"b","displayName":"B"},null],` +
+		`"by_key":{"some_key":{"accountID":"c","displayName":"C"}},` +
+		`"dynamic":{"accountID":"d","displayName":"D"}}`
+
+	output, err := codec.Marshal(model)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	var decoded domainModel
+	if err := codec.Unmarshal(output, &decoded); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		// Values within interfaces can't be matched by type when decoding
+		model.Dynamic = map[string]interface{}{"accountID": "d", "displayName": "D"}
+
+		if !reflect.DeepEqual(model, decoded) {
+			t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, model)
+		}
+	}
+
+	// Registered top-level values use their own transformers
+	if output, _ := codec.Marshal(&vendorModel{"e", "E"}); `{"accountID":"e","displayName":"E"}` != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, `{"accountID":"e","displayName":"E"}`)
+	}
+
+	var vendor vendorModel
+	if err := codec.Unmarshal([]byte(`{"accountID":"f","displayName":"F"}`), &vendor); nil != err || (vendorModel{"f", "F"}) != vendor {
+		t.Errorf("vendor was `%+v` (%v), when expected to be `%+v`", vendor, err, vendorModel{"f", "F"})
+	}
+}
+
+func TestWithTypeTransformers_AssertRegistryIsntShared(t *testing.T) {
+	base := WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false))
+
+	first := NewCodec(WithTransformers(transform.ConventionalKeys()), base)
+	second := NewCodec(WithTransformers(transform.ConventionalKeys()), base, WithTypeTransformers[vendorModel]())
+
+	if expected, output := `{"accountID":"a","displayName":"A"}`, codecOutputOf(first, vendorModel{"a", "A"}); expected != output {
+		t.Errorf("Output %s doesn't match expected %s", output, expected)
+	}
+
+	if expected, output := `{"AccountID":"a","DisplayName":"A"}`, codecOutputOf(second, vendorModel{"a", "A"}); expected != output {
+		t.Errorf("Output %s doesn't match expected %s", output, expected)
+	}
+}
+
+type unknownFieldsModel struct {
+	ImageURL string
+	Items    []codecModel
+	ByKey    map[string]codecModel
+	Vendor   vendorModel
+	Raw      json.RawMessage
+}
+
+const unknownFieldsJSON = `{"image_url":"a","extra":1,` +
+	`"items":[{"is_active":true},{"is_actve":true}],` +
+	`"by_key":{"some_key":{"image_uri":"b"}},` +
+	`"vendor":{"accountID":"c","account_id":"d"},` +
+	`"raw":{"anything":true}}`
+
+func TestDisallowUnknownFields(t *testing.T) {
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+		DisallowUnknownFields(),
+	)
+
+	var decoded unknownFieldsModel
+	err := codec.Unmarshal([]byte(unknownFieldsJSON), &decoded)
+
+	unknownErr, isUnknownErr := err.(*UnknownFieldsError)
+
+	if !isUnknownErr {
+		t.Fatalf("Error (%T) %q isn't an *UnknownFieldsError", err, err)
+	}
+
+	if expected := []string{"extra", "items.1.is_actve", "by_key.some_key.image_uri"}; !reflect.DeepEqual(expected, unknownErr.Paths) {
+		t.Errorf("Paths were `%v`, when expected to be `%v`", unknownErr.Paths, expected)
+	}
+
+	if expected := `conjson: unknown fields "extra", "items.1.is_actve", "by_key.some_key.image_uri"`; expected != err.Error() {
+		t.Errorf("Error message was %q, when expected to be %q", err.Error(), expected)
+	}
+
+	if !reflect.DeepEqual(unknownFieldsModel{}, decoded) {
+		t.Errorf("decoded was `%+v`, when expected to be untouched", decoded)
+	}
+
+	if err := codec.Unmarshal([]byte(codecModelConventionalJSON), &codecModel{}); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+}
+
+func TestDisallowUnknownFields_AssertTransformedStructureIsChecked(t *testing.T) {
+	for _, testCase := range []struct {
+		transformers  []transform.Transformer
+		inputJSON     string
+		expectedPaths []string
+	}{
+		{
+			[]transform.Transformer{transform.Envelope("data"), transform.ConventionalKeys()},
+			`{"data":{"image_url":"a","items":[{"is_active":true}]}}`,
+			nil,
+		},
+		{
+			[]transform.Transformer{transform.Envelope("data"), transform.ConventionalKeys()},
+			`{"data":{"image_url":"a","other_key":true}}`,
+			[]string{"other_key"},
+		},
+		{
+			[]transform.Transformer{transform.Flatten("."), transform.ConventionalKeys()},
+			`{"image_url":"a","items":[{"is_active":true}],"by_key.some_key.image_url":"b"}`,
+			nil,
+		},
+		{
+			[]transform.Transformer{transform.ConventionalKeys(), transform.DropNulls()},
+			`{"extra":null,"image_url":"a","items":[{"is_actve":true}]}`,
+			[]string{"items.0.is_actve"},
+		},
+	} {
+		codec := NewCodec(WithTransformers(testCase.transformers...), DisallowUnknownFields())
+
+		var decoded unknownFieldsModel
+		err := codec.Unmarshal([]byte(testCase.inputJSON), &decoded)
+
+		if nil == testCase.expectedPaths {
+			if nil != err {
+				t.Errorf("Unexpected error (%T) %q", err, err)
+			}
+
+			if "a" != decoded.ImageURL {
+				t.Errorf("decoded was `%+v`, when expected to be decoded", decoded)
+			}
+		} else if unknownErr, isUnknownErr := err.(*UnknownFieldsError); !isUnknownErr {
+			t.Errorf("Error (%T) %q isn't an *UnknownFieldsError", err, err)
+		} else if !reflect.DeepEqual(testCase.expectedPaths, unknownErr.Paths) {
+			t.Errorf("Paths were `%v`, when expected to be `%v`", unknownErr.Paths, testCase.expectedPaths)
+		}
+	}
+}
+
+func TestWithUnknownFieldHandler(t *testing.T) {
+	var paths []string
+
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+		WithUnknownFieldHandler(func(path string) {
+			paths = append(paths, path)
+		}),
+	)
+
+	var decoded unknownFieldsModel
+	decoder := codec.NewDecoder(json.NewDecoder(bytes.NewBufferString(unknownFieldsJSON)))
+
+	if err := decoder.Decode(&decoded); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expected := []string{"extra", "items.1.is_actve", "by_key.some_key.image_uri"}; !reflect.DeepEqual(expected, paths) {
+		t.Errorf("paths were `%v`, when expected to be `%v`", paths, expected)
+	}
+
+	if "a" != decoded.ImageURL || 2 != len(decoded.Items) || !decoded.Items[0].IsActive {
+		t.Errorf("decoded was `%+v`, when expected to be decoded", decoded)
+	}
+}
+
+func TestExactFieldNames(t *testing.T) {
+	type model struct {
+		ImageURL string
+		Vendor   vendorModel
+	}
+
+	const inputJSON = `{"imageURL":"a","imageUrl":"b","image_url":"c",` +
+		`"vendor":{"accountID":"d","accountId":"e","displayName":"f"}}`
+
+	for _, testCase := range []struct {
+		transformers  []transform.Transformer
+		expectedPaths []string
+	}{
+		{
+			[]transform.Transformer{transform.CamelCaseKeys(false)},
+			[]string{"imageUrl", "image_url", "vendor.accountId"},
+		},
+		{
+			[]transform.Transformer{transform.ConventionalKeys()},
+			[]string{"imageURL", "imageUrl", "vendor.accountId"},
+		},
+	} {
+		codec := NewCodec(
+			WithTransformers(testCase.transformers...),
+			WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+			ExactFieldNames(),
+			DisallowUnknownFields(),
+		)
+
+		var decoded model
+		err := codec.Unmarshal([]byte(inputJSON), &decoded)
+
+		if unknownErr, isUnknownErr := err.(*UnknownFieldsError); !isUnknownErr {
+			t.Errorf("Error (%T) %q isn't an *UnknownFieldsError", err, err)
+		} else if !reflect.DeepEqual(testCase.expectedPaths, unknownErr.Paths) {
+			t.Errorf("Paths were `%v`, when expected to be `%v`", unknownErr.Paths, testCase.expectedPaths)
+		}
+
+		// The exact keys of the codec's own convention are accepted
+		exactJSON, _ := codec.Marshal(model{"a", vendorModel{"b", "c"}})
+
+		if err := codec.Unmarshal(exactJSON, &decoded); nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if (model{"a", vendorModel{"b", "c"}}) != decoded {
+			t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, model{"a", vendorModel{"b", "c"}})
+		}
+	}
+}
+
+func TestExactFieldNames_AssertInexactKeysArentDecoded(t *testing.T) {
+	const inputJSON = `{"image_url":"good","IMAGEURL":"bad","is_active":true,"IsActive":false}`
+
+	var paths []string
+
+	for _, option := range []Option{
+		ExactFieldNames(),
+		WithUnknownFieldHandler(func(path string) {
+			paths = append(paths, path)
+		}),
+	} {
+		codec := NewCodec(WithTransformers(transform.ConventionalKeys()), ExactFieldNames(), option)
+
+		var decoded codecModel
+		if err := codec.Unmarshal([]byte(inputJSON), &decoded); nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := (codecModel{"good", true}); expected != decoded {
+			t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, expected)
+		}
+	}
+
+	if expected := []string{"IMAGEURL", "IsActive"}; !reflect.DeepEqual(expected, paths) {
+		t.Errorf("paths were `%v`, when expected to be `%v`", paths, expected)
+	}
+}
+
+func TestExactFieldNames_AssertNamesAreDerivedThroughAnyTransformers(t *testing.T) {
+	type model struct {
+		UserName string
+		Password string
+	}
+
+	expected := model{"u", "x"}
+
+	for _, testCase := range []struct {
+		transformers []transform.Transformer
+		inputJSON    string
+	}{
+		{
+			[]transform.Transformer{transform.ConventionalKeys(), transform.Envelope("data")},
+			`{"data":{"user_name":"u","password":"x"}}`,
+		},
+		{
+			[]transform.Transformer{transform.ConventionalKeys(), transform.Redact([]string{"password"}, nil)},
+			`{"user_name":"u","password":"x"}`,
+		},
+	} {
+		codec := NewCodec(WithTransformers(testCase.transformers...), ExactFieldNames(), DisallowUnknownFields())
+
+		var decoded model
+		if err := codec.Unmarshal([]byte(testCase.inputJSON), &decoded); true {
+			if nil != err {
+				t.Errorf("Unexpected error (%T) %q", err, err)
+			}
+
+			if expected != decoded {
+				t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, expected)
+			}
+		}
+	}
+}
+
+type opaqueMarshalerModel struct {
+	InnerKey string
+}
+
+func (m opaqueMarshalerModel) MarshalJSON() ([]byte, error) {
+	return []byte(`{"InnerKey":"` + m.InnerKey + `"}`), nil
+}
+
+func (m *opaqueMarshalerModel) UnmarshalJSON(data []byte) error {
+	var decoded struct{ InnerKey string }
+	err := json.Unmarshal(data, &decoded)
+	m.InnerKey = decoded.InnerKey
+
+	return err
+}
+
+func TestOpaqueMarshalers(t *testing.T) {
+	type model struct {
+		OuterKey  string
+		Blob      json.RawMessage
+		BlobPtr   *json.RawMessage
+		Marshaler opaqueMarshalerModel
+		Vendor    vendorModel
+		Blobs     map[string]json.RawMessage
+	}
+
+	blob := json.RawMessage(`{"customer_Key":{"CamelKey":1}}`)
+	value := model{"a", blob, &blob, opaqueMarshalerModel{"b"}, vendorModel{"c", "C"}, map[string]json.RawMessage{"key": blob}}
+
+	const expectedJSON = `{"outer_key":"a","blob":{"customer_Key":{"CamelKey":1}},` +
+		`"blob_ptr":{"customer_Key":{"CamelKey":1}},"marshaler":{"InnerKey":"b"},` +
+		`"vendor":{"accountID":"c","displayName":"C"},"blobs":{"key":{"customer_Key":{"CamelKey":1}}}}`
+
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+		OpaqueMarshalers(),
+	)
+
+	output, err := codec.Marshal(value)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	var decoded model
+	if err := codec.Unmarshal(output, &decoded); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if !reflect.DeepEqual(value, decoded) {
+		t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, value)
+	}
+
+	// Without the option, the opaque values are transformed
+	if output := codecOutputOf(NewCodec(WithTransformers(transform.ConventionalKeys())), value); expectedJSON == output {
+		t.Errorf("Output %s was expected to be transformed", output)
+	}
+}
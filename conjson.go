@@ -12,7 +12,13 @@
 package conjson
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/Rican7/conjson/transform"
 )
@@ -29,6 +35,20 @@ type Encoder interface {
 	Encode(interface{}) error
 }
 
+// ContextEncoder is an interface defining a JSON encoder that, in addition to
+// encoding values, is able to encode values within a context.
+type ContextEncoder interface {
+	Encoder
+
+	// EncodeContext takes a context and a value and encodes the JSON
+	// representation of that value to the underlying/inner encoder, running
+	// any context-aware transformers within the given context.
+	//
+	// If the given context is already done, nothing is encoded and the
+	// context's error is returned.
+	EncodeContext(context.Context, interface{}) error
+}
+
 // Decoder is an interface defining a simple JSON decoder, with an interface
 // compatible with `encoding/json.Decoder`.
 type Decoder interface {
@@ -41,32 +61,137 @@ type Decoder interface {
 	Decode(interface{}) error
 }
 
-// marshaler is a structure that wraps a value and a list of transformers to
-// enable JSON marshaling with output transformations.
+// ContextDecoder is an interface defining a JSON decoder that, in addition to
+// decoding values, is able to decode values within a context.
+type ContextDecoder interface {
+	Decoder
+
+	// DecodeContext takes a context and behaves like Decode, running any
+	// context-aware transformers within the given context.
+	//
+	// If the given context is already done, nothing is decoded and the
+	// context's error is returned.
+	DecodeContext(context.Context, interface{}) error
+}
+
+// ArrayDecoder is an interface defining a JSON decoder that, in addition to
+// decoding whole values, is able to decode the elements of a JSON array one at
+// a time.
+type ArrayDecoder interface {
+	ContextDecoder
+
+	// DecodeEach reads the next JSON represented value from the
+	// underlying/inner decoder, which must be a JSON array, and calls the given
+	// function once for each element of that array, in order, with the
+	// element's index and a Decoder that decodes that element.
+	//
+	// Elements are read one at a time, so that arbitrarily large arrays may be
+	// decoded without holding the entire array in memory. The passed Decoder
+	// should be used to decode the element at most once, and is itself an
+	// ArrayDecoder, for elements that are arrays. If the function doesn't
+	// decode the element, the element is skipped.
+	//
+	// Iteration stops at the first non-nil error returned by the function,
+	// and that error is returned.
+	DecodeEach(func(int, Decoder) error) error
+
+	// DecodeEachAt takes a JSON Pointer, as defined by RFC 6901, and behaves
+	// like DecodeEach, but for the JSON array at the given pointer within the
+	// next JSON represented value, such as "/data/items".
+	//
+	// The pointer addresses the value as it's read, before any transformers
+	// have run. The values before the array are skipped one token at a time,
+	// without being held in memory, and the rest of the value is consumed once
+	// the array is, so that the underlying/inner decoder is positioned after
+	// the value. If there's no value at the pointer, ErrPointerNotFound is
+	// returned.
+	DecodeEachAt(string, func(int, Decoder) error) error
+
+	// DecodeEachContext takes a context and behaves like DecodeEach, running
+	// any context-aware transformers within the given context.
+	//
+	// The context is checked before each element is read, and iteration stops
+	// with the context's error once the context is done.
+	DecodeEachContext(context.Context, func(int, Decoder) error) error
+
+	// DecodeEachAtContext takes a context and behaves like DecodeEachAt,
+	// running any context-aware transformers within the given context.
+	DecodeEachAtContext(context.Context, string, func(int, Decoder) error) error
+}
+
+// ConventionProvider is an interface defining a type that declares its own JSON
+// convention, so that the package that owns a type also owns the shape of its
+// JSON, without the use of tags.
+//
+// When a value of a type implementing ConventionProvider is marshaled,
+// unmarshaled, encoded, or decoded by this package, either as the top-level
+// value or nested within another value, the keys of the JSON subtree of that
+// value are decided by the transformers returned by JSONTransformers, instead
+// of by the transformers of the operation (or of any enclosing value).
+//
+// The transformers of the operation and of any enclosing value still see the
+// entire subtree, with its keys as the type's convention decides them, so that
+// transformers of values, such as transform.Redact, transform.Project, and
+// transform.DropNulls, apply within it too.
+//
+// JSONTransformers is called on the zero value of the type, so it must not
+// depend on the state of the value. Transformers registered for the type in a
+// Codec take precedence over the type's own convention.
+type ConventionProvider interface {
+	JSONTransformers() []transform.Transformer
+}
+
+// ErrNotArray is returned when a JSON array was expected, but a different type
+// of JSON value was found.
+var ErrNotArray = errors.New("conjson: JSON value is not an array")
+
+// ErrNotObject is returned when a JSON object was expected, but a different
+// type of JSON value was found.
+var ErrNotObject = errors.New("conjson: JSON value is not an object")
+
+// ErrPointerNotFound is returned when a JSON Pointer doesn't point to any value
+// within the JSON data.
+var ErrPointerNotFound = errors.New("conjson: JSON pointer not found")
+
+// marshaler is a structure that wraps a value and a codec to enable JSON
+// marshaling with output transformations, within a context, optionally
+// respelling the encoded keys.
 type marshaler struct {
-	value        interface{}
-	transformers []transform.Transformer
+	ctx       context.Context
+	value     interface{}
+	codec     *Codec
+	spellings KeySpellings
 }
 
-// unmarshaler is a structure that wraps a value and a list of transformers to
-// enable JSON unmarshaling with input transformations.
+// unmarshaler is a structure that wraps a value and a codec to enable JSON
+// unmarshaling with input transformations, within a context, optionally
+// tracking the decoded fields.
 type unmarshaler struct {
-	value        interface{}
-	transformers []transform.Transformer
+	ctx     context.Context
+	value   interface{}
+	codec   *Codec
+	tracker tracker
 }
 
-// encoder is a structure that wraps an `encoding/json.Encoder` and a list
-// of transformers to enable JSON encoding with output transformations.
+// encoder is a structure that wraps an `encoding/json.Encoder` and a codec to
+// enable JSON encoding with output transformations.
 type encoder struct {
-	inner        *json.Encoder
-	transformers []transform.Transformer
+	inner *json.Encoder
+	codec *Codec
 }
 
-// decoder is a structure that wraps an `encoding/json.Decoder` and a list
-// of transformers to enable JSON decoding with input transformations.
+// decoder is a structure that wraps an `encoding/json.Decoder` and a codec to
+// enable JSON decoding with input transformations.
 type decoder struct {
-	inner        *json.Decoder
-	transformers []transform.Transformer
+	inner *json.Decoder
+	codec *Codec
+}
+
+// contextDecoder is a structure that wraps a decoder to enable JSON decoding
+// within a bound context.
+type contextDecoder struct {
+	*decoder
+	ctx context.Context
 }
 
 // NewMarshaler takes a value and a variable number of `transform.Transformer`s
@@ -76,7 +201,7 @@ type decoder struct {
 // See the documentation for both `encoding/json.Marshaler` and
 // `encoding/json.Marshal` for more details about JSON marshaling.
 func NewMarshaler(value interface{}, transformers ...transform.Transformer) json.Marshaler {
-	return &marshaler{value, transformers}
+	return &marshaler{context.Background(), value, newCodec(ignoreContext(transformers)), nil}
 }
 
 // NewUnmarshaler takes a pointer value and a variable number of
@@ -86,7 +211,7 @@ func NewMarshaler(value interface{}, transformers ...transform.Transformer) json
 // See the documentation for both `encoding/json.Unmarshaler` and
 // `encoding/json.Unmarshal` for more details about JSON unmarshaling.
 func NewUnmarshaler(value interface{}, transformers ...transform.Transformer) json.Unmarshaler {
-	return &unmarshaler{value, transformers}
+	return &unmarshaler{context.Background(), value, newCodec(ignoreContext(transformers)), tracker{}}
 }
 
 // NewEncoder takes an `encoding/json.Encoder` and a variable number of
@@ -96,7 +221,7 @@ func NewUnmarshaler(value interface{}, transformers ...transform.Transformer) js
 // See the documentation for both `encoding/json.Encoder` and
 // `encoding/json.Marshal` for more details about the passed inner encoder.
 func NewEncoder(inner *json.Encoder, transformers ...transform.Transformer) Encoder {
-	return &encoder{inner, transformers}
+	return &encoder{inner, newCodec(ignoreContext(transformers))}
 }
 
 // NewDecoder takes an `encoding/json.Decoder` and a variable number of
@@ -106,33 +231,327 @@ func NewEncoder(inner *json.Encoder, transformers ...transform.Transformer) Enco
 // See the documentation for both `encoding/json.Decoder` and
 // `encoding/json.Unmarshal` for more details about the passed inner decoder.
 func NewDecoder(inner *json.Decoder, transformers ...transform.Transformer) Decoder {
-	return &decoder{inner, transformers}
+	return &decoder{inner, newCodec(ignoreContext(transformers))}
+}
+
+// NewContextEncoder takes an `encoding/json.Encoder` and a variable number of
+// `transform.ContextTransformer`s and returns a `ContextEncoder` that runs the
+// given transformers upon JSON encoding, within the context passed to
+// `ContextEncoder.EncodeContext`.
+//
+// See the documentation for NewEncoder for more details.
+func NewContextEncoder(inner *json.Encoder, transformers ...transform.ContextTransformer) ContextEncoder {
+	return &encoder{inner, newCodec(transformers)}
+}
+
+// NewArrayDecoder takes an `encoding/json.Decoder` and a variable number of
+// `transform.Transformer`s and returns an `ArrayDecoder` that runs the given
+// transformers upon JSON decoding, including upon the decoding of each
+// element of a JSON array.
+//
+// See the documentation for NewDecoder for more details.
+func NewArrayDecoder(inner *json.Decoder, transformers ...transform.Transformer) ArrayDecoder {
+	return &decoder{inner, newCodec(ignoreContext(transformers))}
+}
+
+// NewContextDecoder takes an `encoding/json.Decoder` and a variable number of
+// `transform.ContextTransformer`s and returns an `ArrayDecoder` that runs the
+// given transformers upon JSON decoding, within the context passed to
+// `ContextDecoder.DecodeContext` or `ArrayDecoder.DecodeEachContext`.
+//
+// See the documentation for NewDecoder for more details.
+func NewContextDecoder(inner *json.Decoder, transformers ...transform.ContextTransformer) ArrayDecoder {
+	return &decoder{inner, newCodec(transformers)}
+}
+
+// MarshalContext takes a context, a value, and a variable number of
+// `transform.ContextTransformer`s and returns the JSON encoding of the value,
+// with the given transformers having run on the output within the given
+// context.
+//
+// If the given context is already done, nothing is marshaled and the
+// context's error is returned.
+func MarshalContext(ctx context.Context, value interface{}, transformers ...transform.ContextTransformer) ([]byte, error) {
+	if err := ctx.Err(); nil != err {
+		return nil, err
+	}
+
+	return marshalJSON(&marshaler{ctx, value, newCodec(transformers), nil})
+}
+
+// UnmarshalContext takes a context, JSON encoded data, a pointer value, and a
+// variable number of `transform.ContextTransformer`s and stores the result of
+// decoding the data in the pointed to value, with the given transformers
+// having run on the input within the given context.
+//
+// If the given context is already done, nothing is unmarshaled and the
+// context's error is returned.
+func UnmarshalContext(ctx context.Context, data []byte, value interface{}, transformers ...transform.ContextTransformer) error {
+	if err := ctx.Err(); nil != err {
+		return err
+	}
+
+	return json.Unmarshal(data, &unmarshaler{ctx, value, newCodec(transformers), tracker{}})
 }
 
 func (m *marshaler) MarshalJSON() ([]byte, error) {
-	marshalled, err := json.Marshal(m.value)
+	return m.codec.marshal(m.ctx, m.value, m.spellings)
+}
+
+// marshalJSON takes a marshaler and returns its JSON exactly as the
+// transformers produced it, rather than compacted and HTML-escaped as
+// `encoding/json` does to the output of an `encoding/json.Marshaler`, so that
+// formatting transformers such as transform.Indent take effect. Errors and
+// invalid JSON are reported the same as `encoding/json` reports them.
+func marshalJSON(m *marshaler) ([]byte, error) {
+	data, err := m.MarshalJSON()
 
-	if nil == err {
-		marshalled = transform.Bytes(marshalled, transform.Marshal, m.transformers...)
+	if nil == err && !json.Valid(data) {
+		err = json.Compact(new(bytes.Buffer), data)
 	}
 
-	return marshalled, err
+	if nil != err {
+		return nil, &json.MarshalerError{Type: reflect.TypeOf(m), Err: err}
+	}
+
+	return data, nil
 }
 
 func (um *unmarshaler) UnmarshalJSON(data []byte) error {
-	data = transform.Bytes(data, transform.Unmarshal, um.transformers...)
-
-	return json.Unmarshal(data, um.value)
+	return um.codec.unmarshal(um.ctx, data, um.value, um.tracker)
 }
 
 func (e *encoder) Encode(value interface{}) error {
-	return e.inner.Encode(
-		NewMarshaler(value, e.transformers...),
-	)
+	return e.EncodeContext(context.Background(), value)
+}
+
+func (e *encoder) EncodeContext(ctx context.Context, value interface{}) error {
+	if err := ctx.Err(); nil != err {
+		return err
+	}
+
+	return e.inner.Encode(&marshaler{ctx, value, e.codec, nil})
 }
 
 func (e *decoder) Decode(value interface{}) error {
-	return e.inner.Decode(
-		NewUnmarshaler(value, e.transformers...),
-	)
+	return e.DecodeContext(context.Background(), value)
+}
+
+func (e *decoder) DecodeContext(ctx context.Context, value interface{}) error {
+	if err := ctx.Err(); nil != err {
+		return err
+	}
+
+	return e.inner.Decode(&unmarshaler{ctx, value, e.codec, tracker{}})
+}
+
+func (e *decoder) DecodeEach(fn func(int, Decoder) error) error {
+	return e.DecodeEachAtContext(context.Background(), "", fn)
+}
+
+func (e *decoder) DecodeEachAt(pointer string, fn func(int, Decoder) error) error {
+	return e.DecodeEachAtContext(context.Background(), pointer, fn)
+}
+
+func (e *decoder) DecodeEachContext(ctx context.Context, fn func(int, Decoder) error) error {
+	return e.DecodeEachAtContext(ctx, "", fn)
+}
+
+func (e *decoder) DecodeEachAtContext(ctx context.Context, pointer string, fn func(int, Decoder) error) error {
+	if err := ctx.Err(); nil != err {
+		return err
+	}
+
+	depth, err := e.seek(pointer)
+
+	if nil != err {
+		return err
+	}
+
+	token, err := e.inner.Token()
+
+	if nil != err {
+		return err
+	}
+
+	if delim, isDelim := token.(json.Delim); !isDelim || '[' != delim {
+		return ErrNotArray
+	}
+
+	// Bind the context to the decoder passed for each element, so that the
+	// element is decoded within the same context
+	elementDecoder := &contextDecoder{e, ctx}
+
+	for index := 0; e.inner.More(); index++ {
+		if err := ctx.Err(); nil != err {
+			return err
+		}
+
+		offset := e.inner.InputOffset()
+
+		if err := fn(index, elementDecoder); nil != err {
+			return err
+		}
+
+		// If the function didn't decode the element, skip it so that we're
+		// positioned at the next one
+		if offset == e.inner.InputOffset() {
+			if err := e.skip(); nil != err {
+				return err
+			}
+		}
+	}
+
+	// Consume the closing delimiter of the array
+	if _, err := e.inner.Token(); nil != err {
+		return err
+	}
+
+	// Consume the rest of the values that enclose the array
+	for ; 0 < depth; depth-- {
+		for e.inner.More() {
+			if err := e.skip(); nil != err {
+				return err
+			}
+		}
+
+		if _, err := e.inner.Token(); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seek takes a JSON Pointer and advances the inner decoder to the value at the
+// pointer within the next JSON value, skipping the values before it, and
+// returns the number of objects and arrays that enclose the value.
+func (e *decoder) seek(pointer string) (int, error) {
+	if "" == pointer {
+		return 0, nil
+	}
+
+	if '/' != pointer[0] {
+		return 0, ErrPointerNotFound
+	}
+
+	segments := strings.Split(pointer[1:], "/")
+
+	for depth, segment := range segments {
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		token, err := e.inner.Token()
+
+		if nil != err {
+			return depth, err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			for {
+				if !e.inner.More() {
+					return depth + 1, ErrPointerNotFound
+				}
+
+				key, err := e.inner.Token()
+
+				if nil != err {
+					return depth + 1, err
+				}
+
+				if segment == key {
+					break
+				}
+
+				if err := e.skip(); nil != err {
+					return depth + 1, err
+				}
+			}
+		case json.Delim('['):
+			index, err := strconv.Atoi(segment)
+
+			if nil != err || 0 > index || (1 < len(segment) && '0' == segment[0]) {
+				return depth + 1, ErrPointerNotFound
+			}
+
+			for ; 0 < index; index-- {
+				if !e.inner.More() {
+					return depth + 1, ErrPointerNotFound
+				}
+
+				if err := e.skip(); nil != err {
+					return depth + 1, err
+				}
+			}
+
+			if !e.inner.More() {
+				return depth + 1, ErrPointerNotFound
+			}
+		default:
+			return depth, ErrPointerNotFound
+		}
+	}
+
+	return len(segments), nil
+}
+
+// skip advances the inner decoder past the next JSON value, one token at a
+// time, so that the value is never held in memory.
+func (e *decoder) skip() error {
+	for depth := 0; ; {
+		token, err := e.inner.Token()
+
+		if nil != err {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if 0 == depth {
+			return nil
+		}
+	}
+}
+
+func (d *contextDecoder) Decode(value interface{}) error {
+	return d.DecodeContext(d.ctx, value)
+}
+
+func (d *contextDecoder) DecodeEach(fn func(int, Decoder) error) error {
+	return d.DecodeEachAtContext(d.ctx, "", fn)
+}
+
+func (d *contextDecoder) DecodeEachAt(pointer string, fn func(int, Decoder) error) error {
+	return d.DecodeEachAtContext(d.ctx, pointer, fn)
+}
+
+// transformBytes takes a context, a source bytes of data, a Direction, and a
+// list of `transform.ContextTransformer`s and behaves like `transform.Bytes`,
+// running the given transformers within the given context.
+func transformBytes(ctx context.Context, data []byte, direction transform.Direction, transformers []transform.ContextTransformer) []byte {
+	// Make a copy of the source data, the same as `transform.Bytes`
+	transformed := make([]byte, len(data))
+	copy(transformed, data)
+
+	for _, transformer := range transformers {
+		transformed = transformer(ctx, transformed, direction)
+	}
+
+	return transformed
+}
+
+// ignoreContext takes a list of `transform.Transformer`s and returns a list of
+// `transform.ContextTransformer`s that ignore the context they're run within.
+func ignoreContext(transformers []transform.Transformer) []transform.ContextTransformer {
+	ignoreContext := make([]transform.ContextTransformer, len(transformers))
+
+	for i, transformer := range transformers {
+		ignoreContext[i] = transform.IgnoreContext(transformer)
+	}
+
+	return ignoreContext
 }
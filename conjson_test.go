@@ -2,9 +2,11 @@ package conjson
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"reflect"
 	"testing"
 	"time"
 
@@ -15,8 +17,16 @@ var (
 	// Compile time interface assertion
 	_ json.Marshaler   = (*marshaler)(nil)
 	_ json.Unmarshaler = (*unmarshaler)(nil)
-	_ Encoder          = (*encoder)(nil)
-	_ Decoder          = (*decoder)(nil)
+	_ ContextEncoder   = (*encoder)(nil)
+	_ ArrayDecoder     = (*decoder)(nil)
+	_ ArrayDecoder     = (*contextDecoder)(nil)
+
+	// The inner encoders and decoders remain compatible
+	_ Encoder = (*json.Encoder)(nil)
+	_ Decoder = (*json.Decoder)(nil)
+
+	_ ConventionProvider = providerModel{}
+	_ ConventionProvider = (*pointerProviderModel)(nil)
 )
 
 // Mocks
@@ -57,6 +67,42 @@ func mockTransformer(timesRan *int, directionRan *transform.Direction) transform
 	}
 }
 
+type providerModel struct {
+	AccountID string
+}
+
+type pointerProviderModel struct {
+	AccountID string
+}
+
+type providerParentModel struct {
+	ImageURL string
+	Provider providerModel
+	Pointer  *pointerProviderModel
+}
+
+func (providerModel) JSONTransformers() []transform.Transformer {
+	return []transform.Transformer{transform.CamelCaseKeys(false)}
+}
+
+func (*pointerProviderModel) JSONTransformers() []transform.Transformer {
+	return []transform.Transformer{transform.ValidIdentifierKeys()}
+}
+
+type contextKey struct{}
+
+// contextTransformer returns a transformer that replaces the data with a JSON
+// string of the value stored in the context it's run within.
+func contextTransformer(directionRan *transform.Direction) transform.ContextTransformer {
+	return func(ctx context.Context, data []byte, direction transform.Direction) []byte {
+		*directionRan = direction
+
+		encoded, _ := json.Marshal(ctx.Value(contextKey{}))
+
+		return encoded
+	}
+}
+
 // Tests
 
 func TestNewMarshaler(t *testing.T) {
@@ -490,3 +536,537 @@ func TestDecoder_UnmarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestDecoder_DecodeEach(t *testing.T) {
+	type element struct {
+		ImageURL string
+	}
+
+	testJSONBytes := []byte(`[{"image_url": "a"}, {"image_url": "b"}, {"image_url": "c"}]`)
+
+	var decoded []element
+	var indexes []int
+	buf, timesRan, directionRan := bytes.NewBuffer(testJSONBytes), 0, transform.Marshal
+	if err := NewArrayDecoder(json.NewDecoder(buf), transform.ConventionalKeys(), mockTransformer(&timesRan, &directionRan)).DecodeEach(
+		func(index int, d Decoder) error {
+			var el element
+			err := d.Decode(&el)
+
+			decoded = append(decoded, el)
+			indexes = append(indexes, index)
+
+			return err
+		},
+	); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := []element{{"a"}, {"b"}, {"c"}}; !reflect.DeepEqual(expected, decoded) {
+			t.Errorf("decoded was `%v`, when expected to be `%v`", decoded, expected)
+		}
+
+		if expected := []int{0, 1, 2}; !reflect.DeepEqual(expected, indexes) {
+			t.Errorf("indexes was `%v`, when expected to be `%v`", indexes, expected)
+		}
+
+		if 3 != timesRan {
+			t.Errorf("timesRan was `%d`, when expected to be `3`", timesRan)
+		}
+
+		if transform.Unmarshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Unmarshal")
+		}
+	}
+
+	// Skipped elements shouldn't be decoded, and shouldn't stop iteration
+	decoded = nil
+	buf = bytes.NewBuffer(append(testJSONBytes, []byte(` "trailing"`)...))
+	innerDecoder := json.NewDecoder(buf)
+	if err := NewArrayDecoder(innerDecoder, transform.ConventionalKeys()).DecodeEach(
+		func(index int, d Decoder) error {
+			if 1 == index {
+				return nil
+			}
+
+			var el element
+			err := d.Decode(&el)
+
+			decoded = append(decoded, el)
+
+			return err
+		},
+	); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := []element{{"a"}, {"c"}}; !reflect.DeepEqual(expected, decoded) {
+			t.Errorf("decoded was `%v`, when expected to be `%v`", decoded, expected)
+		}
+
+		var trailing string
+		if err := innerDecoder.Decode(&trailing); nil != err || "trailing" != trailing {
+			t.Errorf("The decoder wasn't left positioned after the array (%q, %v)", trailing, err)
+		}
+	}
+
+	expectedErr := errors.New("expected error")
+	timesRan = 0
+	buf = bytes.NewBuffer(testJSONBytes)
+	if err := NewArrayDecoder(json.NewDecoder(buf)).DecodeEach(
+		func(index int, d Decoder) error {
+			timesRan++
+
+			return expectedErr
+		},
+	); true {
+		if expectedErr != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, expectedErr)
+		}
+
+		if 1 != timesRan {
+			t.Errorf("timesRan was `%d`, when expected to be `1`", timesRan)
+		}
+	}
+
+	buf = bytes.NewBuffer([]byte(`{"not": "an array"}`))
+	if err := NewArrayDecoder(json.NewDecoder(buf)).DecodeEach(
+		func(index int, d Decoder) error {
+			t.Error("Function was called for a non-array value")
+
+			return nil
+		},
+	); ErrNotArray != err {
+		t.Errorf("err was `%v`, when expected to be `%v`", err, ErrNotArray)
+	}
+}
+
+func TestDecoder_DecodeEachAt(t *testing.T) {
+	const testJSON = `{"meta":{"items":[1,2]},"data":{"skipped":[{"a":[]}],"items":[{"image_url":"a"},{"image_url":"b"}],"after":{"x":[1]}}} "trailing"`
+
+	type element struct {
+		ImageURL string
+	}
+
+	var decoded []element
+	innerDecoder := json.NewDecoder(bytes.NewBufferString(testJSON))
+	if err := NewArrayDecoder(innerDecoder, transform.ConventionalKeys()).DecodeEachAt(
+		"/data/items",
+		func(index int, d Decoder) error {
+			var el element
+			err := d.Decode(&el)
+
+			decoded = append(decoded, el)
+
+			return err
+		},
+	); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := []element{{"a"}, {"b"}}; !reflect.DeepEqual(expected, decoded) {
+			t.Errorf("decoded was `%v`, when expected to be `%v`", decoded, expected)
+		}
+
+		var trailing string
+		if err := innerDecoder.Decode(&trailing); nil != err || "trailing" != trailing {
+			t.Errorf("The decoder wasn't left positioned after the value (%q, %v)", trailing, err)
+		}
+	}
+
+	for _, testCase := range []struct {
+		inputJSON   string
+		pointer     string
+		expected    []string
+		expectedErr error
+	}{
+		{`[["a"],["b","c"]]`, "/1", []string{"b", "c"}, nil},
+		{`{"a/b":{"~":["x"]}}`, "/a~1b/~0", []string{"x"}, nil},
+		{`{"data":[]}`, "/data", nil, nil},
+		{`{"data":[]}`, "/missing", nil, ErrPointerNotFound},
+		{`[["a"]]`, "/1", nil, ErrPointerNotFound},
+		{`[["a"]]`, "/01", nil, ErrPointerNotFound},
+		{`{"data":"a"}`, "/data/0", nil, ErrPointerNotFound},
+		{`{"data":{}}`, "/data", nil, ErrNotArray},
+		{`[]`, "data", nil, ErrPointerNotFound},
+	} {
+		var decoded []string
+		err := NewArrayDecoder(json.NewDecoder(bytes.NewBufferString(testCase.inputJSON))).DecodeEachAt(
+			testCase.pointer,
+			func(index int, d Decoder) error {
+				var val string
+				err := d.Decode(&val)
+
+				decoded = append(decoded, val)
+
+				return err
+			},
+		)
+
+		if testCase.expectedErr != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, testCase.expectedErr)
+		}
+
+		if !reflect.DeepEqual(testCase.expected, decoded) {
+			t.Errorf("decoded was `%v`, when expected to be `%v`", decoded, testCase.expected)
+		}
+	}
+}
+
+func TestNewContextEncoder(t *testing.T) {
+	testJSONEncoder := json.NewEncoder(ioutil.Discard)
+	noOpContextTransformer := transform.IgnoreContext(noOpTransformer)
+
+	if _, isEncoder := NewContextEncoder(nil).(Encoder); !isEncoder {
+		t.Error("NewContextEncoder didn't return a type compatible with Encoder")
+	}
+
+	if _, isEncoder := NewContextEncoder(testJSONEncoder).(Encoder); !isEncoder {
+		t.Error("NewContextEncoder didn't return a type compatible with Encoder")
+	}
+
+	if _, isEncoder := NewContextEncoder(testJSONEncoder, noOpContextTransformer, noOpContextTransformer).(Encoder); !isEncoder {
+		t.Error("NewContextEncoder didn't return a type compatible with Encoder")
+	}
+}
+
+func TestNewContextDecoder(t *testing.T) {
+	testJSONDecoder := json.NewDecoder(&bytes.Buffer{})
+	noOpContextTransformer := transform.IgnoreContext(noOpTransformer)
+
+	if _, isDecoder := NewContextDecoder(nil).(Decoder); !isDecoder {
+		t.Error("NewContextDecoder didn't return a type compatible with Decoder")
+	}
+
+	if _, isDecoder := NewContextDecoder(testJSONDecoder).(Decoder); !isDecoder {
+		t.Error("NewContextDecoder didn't return a type compatible with Decoder")
+	}
+
+	if _, isDecoder := NewContextDecoder(testJSONDecoder, noOpContextTransformer, noOpContextTransformer).(Decoder); !isDecoder {
+		t.Error("NewContextDecoder didn't return a type compatible with Decoder")
+	}
+}
+
+func TestMarshalContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "context value")
+	directionRan := transform.Unmarshal
+
+	if output, err := MarshalContext(ctx, true, contextTransformer(&directionRan)); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := `"context value"`; expected != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, expected)
+		}
+
+		if transform.Marshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Marshal")
+		}
+	}
+
+	if output, err := MarshalContext(ctx, map[string]int{"someKey": 1}, transform.IgnoreContext(transform.ConventionalKeys())); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := `{"some_key":1}`; expected != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, expected)
+		}
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	directionRan = transform.Unmarshal
+	if _, err := MarshalContext(cancelledCtx, true, contextTransformer(&directionRan)); true {
+		if context.Canceled != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, context.Canceled)
+		}
+
+		if transform.Unmarshal != directionRan {
+			t.Error("Transformer was run for an already done context")
+		}
+	}
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "context value")
+	directionRan := transform.Marshal
+
+	var val string
+	if err := UnmarshalContext(ctx, []byte(`"input value"`), &val, contextTransformer(&directionRan)); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "context value"; expected != val {
+			t.Errorf("val was %q, when expected to be %q", val, expected)
+		}
+
+		if transform.Unmarshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Unmarshal")
+		}
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	val, directionRan = "", transform.Marshal
+	if err := UnmarshalContext(cancelledCtx, []byte(`"input value"`), &val, contextTransformer(&directionRan)); true {
+		if context.Canceled != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, context.Canceled)
+		}
+
+		if "" != val || transform.Marshal != directionRan {
+			t.Error("Value was unmarshaled for an already done context")
+		}
+	}
+}
+
+func TestEncoder_EncodeContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "context value")
+	directionRan := transform.Unmarshal
+
+	var buf bytes.Buffer
+	if err := NewContextEncoder(json.NewEncoder(&buf), contextTransformer(&directionRan)).EncodeContext(ctx, true); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "\"context value\"\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+
+		if transform.Marshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Marshal")
+		}
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	buf = bytes.Buffer{}
+	if err := NewContextEncoder(json.NewEncoder(&buf), contextTransformer(&directionRan)).EncodeContext(cancelledCtx, true); true {
+		if context.Canceled != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, context.Canceled)
+		}
+
+		if 0 != buf.Len() {
+			t.Errorf("Output %q was written for an already done context", buf.String())
+		}
+	}
+}
+
+func TestDecoder_DecodeContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "context value")
+	directionRan := transform.Marshal
+
+	var val string
+	buf := bytes.NewBufferString(`"input value"`)
+	if err := NewContextDecoder(json.NewDecoder(buf), contextTransformer(&directionRan)).DecodeContext(ctx, &val); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "context value"; expected != val {
+			t.Errorf("val was %q, when expected to be %q", val, expected)
+		}
+
+		if transform.Unmarshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Unmarshal")
+		}
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	val, buf = "", bytes.NewBufferString(`"input value"`)
+	if err := NewContextDecoder(json.NewDecoder(buf), contextTransformer(&directionRan)).DecodeContext(cancelledCtx, &val); true {
+		if context.Canceled != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, context.Canceled)
+		}
+
+		if "" != val {
+			t.Errorf("val was %q for an already done context", val)
+		}
+	}
+}
+
+func TestDecoder_DecodeEachContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "context value"))
+	defer cancel()
+	directionRan := transform.Marshal
+
+	var decoded []string
+	buf := bytes.NewBufferString(`["a", "b", "c"]`)
+	if err := NewContextDecoder(json.NewDecoder(buf), contextTransformer(&directionRan)).DecodeEachContext(
+		ctx,
+		func(index int, d Decoder) error {
+			var val string
+			err := d.Decode(&val)
+
+			decoded = append(decoded, val)
+
+			// Cancel the context part way through the iteration
+			if 1 == index {
+				cancel()
+			}
+
+			return err
+		},
+	); true {
+		if context.Canceled != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, context.Canceled)
+		}
+
+		if expected := []string{"context value", "context value"}; !reflect.DeepEqual(expected, decoded) {
+			t.Errorf("decoded was `%v`, when expected to be `%v`", decoded, expected)
+		}
+
+		if transform.Unmarshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Unmarshal")
+		}
+	}
+}
+
+func TestConventionProvider(t *testing.T) {
+	model := providerParentModel{
+		ImageURL: "https://example.com/image.png",
+		Provider: providerModel{"a"},
+		Pointer:  &pointerProviderModel{"b"},
+	}
+
+	const expectedJSON = `{"image_url":"https://example.com/image.png","provider":{"accountID":"a"},"pointer":{"AccountID":"b"}}`
+
+	if output, err := json.Marshal(NewMarshaler(model, transform.ConventionalKeys())); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expectedJSON != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(json.NewEncoder(&buf), transform.ConventionalKeys()).Encode(model); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expectedJSON+"\n" != buf.String() {
+			t.Errorf("Output %s doesn't match expected %s", buf.String(), expectedJSON)
+		}
+	}
+
+	var decoded providerParentModel
+	if err := json.Unmarshal([]byte(expectedJSON), NewUnmarshaler(&decoded, transform.ConventionalKeys())); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if !reflect.DeepEqual(model, decoded) {
+			t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, model)
+		}
+	}
+
+	decoded = providerParentModel{}
+	if err := NewDecoder(json.NewDecoder(&buf), transform.ConventionalKeys()).Decode(&decoded); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if !reflect.DeepEqual(model, decoded) {
+			t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, model)
+		}
+	}
+
+	// The top-level value's own convention takes over
+	if output, _ := json.Marshal(NewMarshaler(providerModel{"c"}, transform.ConventionalKeys())); `{"accountID":"c"}` != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, `{"accountID":"c"}`)
+	}
+
+	// A codec's registered types take precedence
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()), WithTypeTransformers[providerModel]())
+	if output, _ := codec.Marshal(providerModel{"d"}); `{"AccountID":"d"}` != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, `{"AccountID":"d"}`)
+	}
+}
+
+type countingProviderModel struct {
+	AccountID string
+}
+
+var countingProviderCalls int
+
+func (countingProviderModel) JSONTransformers() []transform.Transformer {
+	countingProviderCalls++
+
+	return nil
+}
+
+func TestConventionProvider_AssertConventionIsCached(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		if output, _ := json.Marshal(NewMarshaler([]countingProviderModel{{"a"}, {"b"}}, transform.ConventionalKeys())); `[{"AccountID":"a"},{"AccountID":"b"}]` != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, `[{"AccountID":"a"},{"AccountID":"b"}]`)
+		}
+	}
+
+	if 1 != countingProviderCalls {
+		t.Errorf("JSONTransformers was called `%d` times, when expected to be called once", countingProviderCalls)
+	}
+}
+
+func TestRedact_AssertSecretsAreRedactedAtAnyDepth(t *testing.T) {
+	type registeredModel struct {
+		ApiToken string
+	}
+
+	type model struct {
+		Password   string
+		Vendor     secretVendorModel
+		Registered registeredModel
+		Isolated   Value[registeredModel, camelCaseKeys]
+		Extra      Extras
+	}
+
+	value := model{
+		Password:   "p1",
+		Vendor:     secretVendorModel{Password: "p2", ApiToken: "tok"},
+		Registered: registeredModel{"tok"},
+		Isolated:   NewValue[camelCaseKeys](registeredModel{"tok"}),
+		Extra:      Extras{"session_token": json.RawMessage(`"tok"`)},
+	}
+
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys(), transform.Redact([]string{"password", "*_token"}, nil)),
+		WithTypeTransformers[registeredModel](transform.CamelCaseKeys(false)),
+	)
+
+	const expectedJSON = `{"vendor":{"email":"","secret":null,"count":0,"createdAt":"0001-01-01T00:00:00Z"},"registered":{},"isolated":{}}`
+
+	if output, err := codec.Marshal(value); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expectedJSON != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(json.NewEncoder(&buf)).Encode(value); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expectedJSON+"\n" != buf.String() {
+			t.Errorf("Output %s doesn't match expected %s", buf.String(), expectedJSON)
+		}
+	}
+
+	spellings := KeySpellings{"Password": "PASSWORD", "Vendor.ApiToken": "API_TOKEN"}
+	if output, _ := codec.MarshalKeySpellings(value, spellings); expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+}
@@ -0,0 +1,120 @@
+package conjson
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// UnknownFieldsError is returned when unmarshaling or decoding with a Codec
+// that disallows unknown fields, and the JSON data contains object keys that
+// don't map to any field of the struct that the object is decoded into.
+type UnknownFieldsError struct {
+	// Paths is the list of the JSON paths of the unknown keys, in the order
+	// that they appear in the data, using the keys of the original data
+	// joined with dots, and the indexes of array elements.
+	Paths []string
+}
+
+// Error satisfies the error interface by describing the unknown fields.
+func (e *UnknownFieldsError) Error() string {
+	quoted := make([]string, len(e.Paths))
+
+	for i, path := range e.Paths {
+		quoted[i] = strconv.Quote(path)
+	}
+
+	return "conjson: unknown fields " + strings.Join(quoted, ", ")
+}
+
+// translateError takes an error returned from decoding transformed JSON data,
+// the original data, and the transformed data, and returns an equivalent error
+// that describes the position and the path of the error in terms of the
+// original data, rather than the transformed data.
+//
+// Errors that can't be related back to the original data are returned as-is.
+func translateError(err error, original, transformed []byte) error {
+	typeErr, isTypeErr := err.(*json.UnmarshalTypeError)
+
+	if !isTypeErr {
+		return err
+	}
+
+	originalRoot, originalErr := jsontree.Parse(original)
+	transformedRoot, transformedErr := jsontree.Parse(transformed)
+
+	if nil != originalErr || nil != transformedErr {
+		return err
+	}
+
+	path, originalNode, transformedNode := locate(originalRoot, transformedRoot, int(typeErr.Offset))
+
+	// Keep the same relative offset within the located value
+	offset := originalNode.Start + (int(typeErr.Offset) - transformedNode.Start)
+
+	if offset > originalNode.End {
+		offset = originalNode.End
+	}
+
+	translated := *typeErr
+	translated.Field = path
+	translated.Offset = int64(offset)
+
+	return &translated
+}
+
+// locate takes the root nodes of an original document and of its transformed
+// form, and an offset within the transformed document, and walks both
+// documents in parallel to find the innermost value that spans the offset. It
+// returns the path of the value, using the keys of the original document, and
+// the value's node in each document.
+//
+// The walk stops early if the documents' structures diverge.
+func locate(original, transformed *jsontree.Node, offset int) (string, *jsontree.Node, *jsontree.Node) {
+	var path []string
+
+	for {
+		var next int
+		var found bool
+
+		switch {
+		case jsontree.Object == transformed.Kind && jsontree.Object == original.Kind && len(transformed.Members) == len(original.Members):
+			for i, member := range transformed.Members {
+				if spans(member.Value, offset) {
+					next, found = i, true
+				}
+			}
+
+			if found {
+				path = append(path, original.Members[next].Key)
+				original, transformed = original.Members[next].Value, transformed.Members[next].Value
+
+				continue
+			}
+		case jsontree.Array == transformed.Kind && jsontree.Array == original.Kind && len(transformed.Elements) == len(original.Elements):
+			for i, element := range transformed.Elements {
+				if spans(element, offset) {
+					next, found = i, true
+				}
+			}
+
+			if found {
+				path = append(path, strconv.Itoa(next))
+				original, transformed = original.Elements[next], transformed.Elements[next]
+
+				continue
+			}
+		}
+
+		return strings.Join(path, "."), original, transformed
+	}
+}
+
+// spans takes a node and an offset reported by `encoding/json` and returns
+// whether the offset is within the node's value, given that the reported
+// offsets are those of the byte following the decoded token.
+func spans(node *jsontree.Node, offset int) bool {
+	return node.Start < offset && offset <= node.End
+}
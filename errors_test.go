@@ -0,0 +1,81 @@
+package conjson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+type errorItemModel struct {
+	ImageURL int
+}
+
+type errorModel struct {
+	Title string
+	Items []errorItemModel
+}
+
+func TestTranslateError(t *testing.T) {
+	for _, testCase := range []struct {
+		input         string
+		transformers  []transform.Transformer
+		expectedField string
+		expectedValue string
+	}{
+		{
+			`{"title": "x", "items": [{"image_url": 1}, {"image_url": "bad"}]}`,
+			[]transform.Transformer{transform.ConventionalKeys()},
+			"items.1.image_url",
+			`{"title": "x", "items": [{"image_url": 1}, {"image_url": "bad"`,
+		},
+		{
+			`{"$title--": {"nested": true}}`,
+			[]transform.Transformer{transform.ConventionalKeys(), transform.ValidIdentifierKeys()},
+			"$title--",
+			`{"$title--": {`,
+		},
+		{
+			`{"items": {"not": "an array"}}`,
+			[]transform.Transformer{transform.ConventionalKeys()},
+			"items",
+			`{"items": {`,
+		},
+		{
+			`["not an object"]`,
+			[]transform.Transformer{transform.ConventionalKeys()},
+			"",
+			`[`,
+		},
+	} {
+		var model errorModel
+		err := json.Unmarshal([]byte(testCase.input), NewUnmarshaler(&model, testCase.transformers...))
+
+		typeErr, isTypeErr := err.(*json.UnmarshalTypeError)
+
+		if !isTypeErr {
+			t.Errorf("Error (%T) %q isn't a *json.UnmarshalTypeError", err, err)
+
+			continue
+		}
+
+		if testCase.expectedField != typeErr.Field {
+			t.Errorf("Field was %q, when expected to be %q", typeErr.Field, testCase.expectedField)
+		}
+
+		if value := testCase.input[:typeErr.Offset]; testCase.expectedValue != value {
+			t.Errorf("Offset was at the end of %q, when expected to be at the end of %q", value, testCase.expectedValue)
+		}
+	}
+
+	otherErr := errors.New("other error")
+	if err := translateError(otherErr, nil, nil); otherErr != err {
+		t.Errorf("err was `%v`, when expected to be `%v`", err, otherErr)
+	}
+
+	typeErr := &json.UnmarshalTypeError{Field: "someField", Offset: 3}
+	if err := translateError(typeErr, []byte(`{`), []byte(`{}`)); typeErr != err {
+		t.Errorf("err was `%v`, when expected to be `%v`", err, typeErr)
+	}
+}
@@ -142,7 +142,7 @@ func ExampleDecoder() {
 
 	var model exampleModel
 
-	decoder := conjson.NewDecoder(
+	decoder := conjson.NewArrayDecoder(
 		json.NewDecoder(bytes.NewBufferString(sampleJSON)),
 		transform.ConventionalKeys(),
 		transform.ValidIdentifierKeys(),
@@ -164,3 +164,84 @@ func ExampleDecoder() {
 	//     "UpdatedAt": "2018-12-24T13:21:15-07:00"
 	// }
 }
+
+func ExampleArrayDecoder_DecodeEach() {
+	sampleJSON := `
+[
+	{"title": "First", "image_url": "https://example.com/first.png"},
+	{"title": "Second", "image_url": "https://example.com/second.png"},
+	{"title": "Third", "image_url": "https://example.com/third.png"}
+]
+`
+
+	decoder := conjson.NewArrayDecoder(
+		json.NewDecoder(bytes.NewBufferString(sampleJSON)),
+		transform.ConventionalKeys(),
+	)
+
+	decoder.DecodeEach(func(index int, d conjson.Decoder) error {
+		var model exampleModel
+
+		if err := d.Decode(&model); nil != err {
+			return err
+		}
+
+		fmt.Println(index, model.Title, model.ImageURL)
+
+		return nil
+	})
+
+	// Output:
+	// 0 First https://example.com/first.png
+	// 1 Second https://example.com/second.png
+	// 2 Third https://example.com/third.png
+}
+
+func ExampleCodec() {
+	codec := conjson.NewCodec(
+		conjson.WithTransformers(transform.ConventionalKeys()),
+	)
+
+	model := exampleModel{
+		Title:    "Example Title",
+		ImageURL: "https://example.com/image.png",
+		IsActive: true,
+	}
+
+	encoded, _ := codec.Marshal(model)
+	fmt.Println(string(encoded))
+
+	var decoded exampleModel
+	codec.Unmarshal(encoded, &decoded)
+	fmt.Println(decoded.Title, decoded.ImageURL, decoded.IsActive)
+
+	// Output:
+	// {"title":"Example Title","description":"","image_url":"https://example.com/image.png","referred_by_url":"","is_active":true,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}
+	// Example Title https://example.com/image.png true
+}
+
+// vendorAccount is a type that declares its own JSON convention.
+type vendorAccount struct {
+	AccountID   string
+	DisplayName string
+}
+
+func (vendorAccount) JSONTransformers() []transform.Transformer {
+	return []transform.Transformer{transform.CamelCaseKeys(false)}
+}
+
+func ExampleConventionProvider() {
+	model := struct {
+		OwnerName string
+		Account   vendorAccount
+	}{
+		OwnerName: "Example Owner",
+		Account:   vendorAccount{"a-123", "Example Account"},
+	}
+
+	encoded, _ := json.Marshal(conjson.NewMarshaler(model, transform.ConventionalKeys()))
+
+	fmt.Println(string(encoded))
+	// Output:
+	// {"owner_name":"Example Owner","account":{"accountID":"a-123","displayName":"Example Account"}}
+}
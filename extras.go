@@ -0,0 +1,171 @@
+package conjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// Extras is a set of JSON object members, by their keys, that designates the
+// struct field that captures the members of a JSON object that don't map to
+// any other field of the struct.
+//
+// When unmarshaling or decoding into a struct with a field of type Extras, the
+// members whose keys don't map to any other field of the struct, once the keys
+// are transformed, are stored in the field by their original keys, with their
+// original values, rather than being dropped or reported as unknown fields.
+//
+// When marshaling or encoding a struct with a field of type Extras, the members
+// stored in the field are emitted in place of the field's member, before the
+// transformers run, with their keys kept as they are. The transformers still
+// see the members, so that their values are redacted or otherwise transformed
+// like any other value. Members whose keys are the same as those of the
+// object's other members, once transformed, are dropped, so that the fields
+// always take precedence.
+//
+// A struct's first field of type Extras is its designated field, and neither
+// the field's own name nor any of its struct tags are used.
+type Extras map[string]json.RawMessage
+
+// rewrite is a structure defining how the members of a JSON object are
+// rewritten to move the object's extra members into the member of its
+// struct's Extras field, or to drop the members that must not be decoded,
+// when unmarshaling.
+type rewrite struct {
+	// name is the key of the member of the object's Extras field
+	name string
+
+	// captured are the members to capture into the member of the object's
+	// Extras field, by the indexes of the object's members that they replace
+	captured map[int][]byte
+
+	// dropped is the set of the indexes of the object's members to drop
+	dropped map[int]bool
+}
+
+var extrasType = reflect.TypeOf(Extras(nil))
+
+// isolatedJSON marks Extras as isolated, so that the keys of the extra members
+// are never transformed.
+func (Extras) isolatedJSON() {}
+
+// extrasField takes a list of fields and returns the first field of type
+// Extras, and whether one was found.
+func extrasField(fields []field) (field, bool) {
+	for _, f := range fields {
+		if extrasType == f.typ {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
+
+// capture takes a member of the transformed document, the member of the
+// original document that it was transformed from, if it's known, and the trace
+// of the transformed document, and returns the member to capture into the
+// member of its struct's Extras field, with its original key and value, if
+// they're known.
+func (w *walker) capture(member, original *jsontree.Member, tr *trace) []byte {
+	if nil != original {
+		return w.data[original.KeyStart:original.Value.End]
+	}
+
+	return tr.data[member.KeyStart:member.Value.End]
+}
+
+// rewriteObject takes a rewrite, the node of an object of the transformed
+// document, the transformed document, and the rewritten values of the
+// object's members, and returns the rewritten object.
+func rewriteObject(r *rewrite, node *jsontree.Node, data []byte, values [][]byte) []byte {
+	var members, extras [][]byte
+
+	for i, member := range node.Members {
+		if captured, isCaptured := r.captured[i]; isCaptured {
+			extras = append(extras, captured)
+		} else if !r.dropped[i] {
+			members = append(members, append(append([]byte(nil), data[member.KeyStart:member.Value.Start]...), values[i]...))
+		}
+	}
+
+	if 0 < len(extras) {
+		extrasMember := append(quote(r.name), ":{"...)
+		extrasMember = append(append(extrasMember, bytes.Join(extras, []byte(","))...), '}')
+		members = append(members, extrasMember)
+	}
+
+	return append(append([]byte{'{'}, bytes.Join(members, []byte(","))...), '}')
+}
+
+// splice takes a document, a node of the document, a list of the node's
+// children, and the replacements of the children, and returns the node's
+// value with each child replaced.
+func splice(data []byte, node *jsontree.Node, children []*jsontree.Node, replacements [][]byte) []byte {
+	var spliced []byte
+	offset := node.Start
+
+	for i, child := range children {
+		spliced = append(spliced, data[offset:child.Start]...)
+		spliced = append(spliced, replacements[i]...)
+		offset = child.End
+	}
+
+	return append(spliced, data[offset:node.End]...)
+}
+
+// dropExtras takes the trace of a transformed region of the document and the
+// set of the region's members that are the extra members of an Extras field,
+// and returns the transformed region without the extra members whose keys are
+// the same as those of the other members of their object, so that the fields
+// always take precedence, when marshaling.
+func dropExtras(tr *trace, extras map[*jsontree.Member]bool) []byte {
+	if 0 == len(extras) || nil == tr.root {
+		return tr.data
+	}
+
+	var edits []edit
+
+	var walk func(node *jsontree.Node)
+	walk = func(node *jsontree.Node) {
+		keys := make(map[string]bool, len(node.Members))
+
+		for i := range node.Members {
+			if !extras[tr.memberOrigin(&node.Members[i])] {
+				keys[node.Members[i].Key] = true
+			}
+		}
+
+		isKept := false
+
+		for i := range node.Members {
+			member := &node.Members[i]
+
+			switch {
+			case !extras[tr.memberOrigin(member)]:
+				isKept = true
+			case keys[member.Key]:
+				// Remove the comma before the member, unless it's preceded only
+				// by removed members, so that the separators stay valid
+				if isKept {
+					edits = append(edits, edit{node.Members[i-1].Value.End, member.Value.End, nil})
+				} else if i+1 < len(node.Members) {
+					edits = append(edits, edit{member.KeyStart, node.Members[i+1].KeyStart, nil})
+				} else {
+					edits = append(edits, edit{member.KeyStart, member.Value.End, nil})
+				}
+			default:
+				keys[member.Key], isKept = true, true
+			}
+		}
+
+		for _, child := range childNodes(node) {
+			walk(child)
+		}
+	}
+
+	walk(tr.root)
+
+	return applyEdits(tr.data, 0, len(tr.data), edits)
+}
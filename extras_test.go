@@ -0,0 +1,134 @@
+package conjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+type extrasItemModel struct {
+	ImageURL string
+	Extra    Extras
+}
+
+type extrasModel struct {
+	ImageURL string
+	Rest     Extras
+	Items    []extrasItemModel
+	Vendor   vendorModel
+}
+
+func TestExtras(t *testing.T) {
+	const inputJSON = `{"image_url":"a","some_Key":{"nested_key":[1, 2]},"rest":"x",` +
+		`"items":[{"image_url":"b","item_key":true},{"image_url":"c"}],` +
+		`"vendor":{"accountID":"d","unknown":1}}`
+
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+	)
+
+	var decoded extrasModel
+	if err := codec.Unmarshal([]byte(inputJSON), &decoded); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expected := extrasModel{
+		ImageURL: "a",
+		Rest: Extras{
+			"some_Key": json.RawMessage(`{"nested_key":[1, 2]}`),
+			"rest":     json.RawMessage(`"x"`),
+		},
+		Items: []extrasItemModel{
+			{"b", Extras{"item_key": json.RawMessage(`true`)}},
+			{"c", nil},
+		},
+		Vendor: vendorModel{AccountID: "d"},
+	}
+
+	if !reflect.DeepEqual(expected, decoded) {
+		t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, expected)
+	}
+
+	output, err := codec.Marshal(decoded)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	const expectedJSON = `{"image_url":"a","rest":"x","some_Key":{"nested_key":[1,2]},` +
+		`"items":[{"image_url":"b","item_key":true},{"image_url":"c"}],` +
+		`"vendor":{"accountID":"d","displayName":""}}`
+
+	if expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+}
+
+func TestExtras_AssertFieldsTakePrecedence(t *testing.T) {
+	value := extrasItemModel{"a", Extras{"image_url": json.RawMessage(`"b"`), "ImageURL": json.RawMessage(`"c"`)}}
+
+	const expectedJSON = `{"image_url":"a","ImageURL":"c"}`
+
+	if output, _ := Marshal(value, transform.ConventionalKeys()); expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+}
+
+func TestExtras_AssertExtrasArentUnknownFields(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()), DisallowUnknownFields())
+
+	var decoded extrasItemModel
+	if err := codec.Unmarshal([]byte(`{"image_url":"a","other":1}`), &decoded); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expected := (Extras{"other": json.RawMessage(`1`)}); !reflect.DeepEqual(expected, decoded.Extra) {
+		t.Errorf("Extra was `%v`, when expected to be `%v`", decoded.Extra, expected)
+	}
+}
+
+func TestExtras_AssertExtrasFollowTheirMember(t *testing.T) {
+	type model struct {
+		Zed   int
+		Extra Extras
+	}
+
+	value := model{3, Extras{"a": json.RawMessage(`1`), "b": json.RawMessage(`2`), "zed": json.RawMessage(`4`)}}
+
+	for _, testCase := range []struct {
+		transformers []transform.Transformer
+		expectedJSON string
+	}{
+		{[]transform.Transformer{transform.ConventionalKeys(), transform.Canonical()}, `{"a":1,"b":2,"zed":3}`},
+		{[]transform.Transformer{transform.ConventionalKeys(), transform.Envelope("data")}, `{"data":{"zed":3,"a":1,"b":2}}`},
+		{[]transform.Transformer{transform.Envelope("data", transform.EnvelopeArray())}, `{"data":[{"Zed":3,"a":1,"b":2,"zed":4}]}`},
+	} {
+		if output, _ := Marshal(value, testCase.transformers...); testCase.expectedJSON != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, testCase.expectedJSON)
+		}
+	}
+
+	if output, _ := Marshal(model{Zed: 3}, transform.ConventionalKeys(), transform.Canonical()); `{"zed":3}` != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, `{"zed":3}`)
+	}
+}
+
+func TestExtras_AssertExtrasAreCapturedFromTransformedStructure(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.Envelope("data"), transform.ConventionalKeys()))
+
+	var decoded extrasItemModel
+	err := codec.Unmarshal([]byte(`{"data":{"other_key":1,"image_url":"a","item_key":true}}`), &decoded)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expected := extrasItemModel{"a", Extras{"other_key": json.RawMessage(`1`), "item_key": json.RawMessage(`true`)}}
+
+	if !reflect.DeepEqual(expected, decoded) {
+		t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, expected)
+	}
+}
@@ -0,0 +1,287 @@
+package conjson
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// field is a structure defining a struct field, as seen by `encoding/json`,
+// with both its JSON name and its Go name.
+type field struct {
+	name   string
+	goName string
+	index  []int
+	typ    reflect.Type
+
+	// omitEmpty is whether the field is omitted when its value is empty, with
+	// the "omitempty" tag option
+	omitEmpty bool
+
+	// quoted is whether the field's value is encoded within a JSON string,
+	// with the "string" tag option
+	quoted bool
+}
+
+var (
+	fieldCache sync.Map // map[reflect.Type][]field
+
+	marshalerType       = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// typeFields takes a struct type and returns the fields of that type that
+// `encoding/json` would encode and decode, following the same rules for
+// field names, tags, and the promotion of the fields of embedded structs.
+func typeFields(t reflect.Type) []field {
+	if cached, isCached := fieldCache.Load(t); isCached {
+		return cached.([]field)
+	}
+
+	type candidate struct {
+		field
+		depth  int
+		tagged bool
+	}
+
+	var candidates []candidate
+	visited := map[reflect.Type]bool{}
+	current := []candidate{{field: field{typ: t}}}
+
+	// Walk the embedded structs breadth-first, so that shallower fields are
+	// found before deeper fields
+	for depth := 0; 0 < len(current); depth++ {
+		var next []candidate
+
+		for _, parent := range current {
+			if visited[parent.typ] {
+				continue
+			}
+
+			visited[parent.typ] = true
+
+			for i := 0; i < parent.typ.NumField(); i++ {
+				structField := parent.typ.Field(i)
+				fieldType := structField.Type
+
+				if structField.Anonymous {
+					if fieldType.Kind() == reflect.Pointer {
+						fieldType = fieldType.Elem()
+					}
+
+					if !structField.IsExported() && fieldType.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !structField.IsExported() {
+					continue
+				}
+
+				tag := structField.Tag.Get("json")
+
+				if "-" == tag {
+					continue
+				}
+
+				name, options, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), parent.index...), i)
+
+				// Untagged embedded structs have their fields promoted
+				if "" == name && structField.Anonymous && fieldType.Kind() == reflect.Struct {
+					next = append(next, candidate{field: field{index: index, typ: fieldType}})
+
+					continue
+				}
+
+				tagged := "" != name
+
+				if !tagged {
+					name = structField.Name
+				}
+
+				f := field{
+					name,
+					structField.Name,
+					index,
+					structField.Type,
+					hasOption(options, "omitempty"),
+					hasOption(options, "string") && isQuotable(structField.Type),
+				}
+
+				candidates = append(candidates, candidate{f, depth, tagged})
+			}
+		}
+
+		current = next
+	}
+
+	// Resolve conflicting names, the same as `encoding/json`: the shallowest
+	// field wins, then a tagged field wins, and otherwise all are dropped
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].name != candidates[j].name {
+			return candidates[i].name < candidates[j].name
+		}
+
+		if candidates[i].depth != candidates[j].depth {
+			return candidates[i].depth < candidates[j].depth
+		}
+
+		return candidates[i].tagged && !candidates[j].tagged
+	})
+
+	var fields []field
+
+	for i := 0; i < len(candidates); {
+		j := i + 1
+
+		for j < len(candidates) && candidates[j].name == candidates[i].name {
+			j++
+		}
+
+		dominant := candidates[i]
+		conflicted := j > i+1 &&
+			candidates[i+1].depth == dominant.depth &&
+			candidates[i+1].tagged == dominant.tagged
+
+		if !conflicted {
+			fields = append(fields, dominant.field)
+		}
+
+		i = j
+	}
+
+	// Keep the fields in their declaration order
+	sort.Slice(fields, func(i, j int) bool {
+		for k := 0; k < len(fields[i].index) && k < len(fields[j].index); k++ {
+			if fields[i].index[k] != fields[j].index[k] {
+				return fields[i].index[k] < fields[j].index[k]
+			}
+		}
+
+		return len(fields[i].index) < len(fields[j].index)
+	})
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+
+	return cached.([]field)
+}
+
+// hasOption takes the options of a struct tag and an option and returns
+// whether the option is one of the options.
+func hasOption(options, option string) bool {
+	for "" != options {
+		var current string
+
+		if current, options, _ = strings.Cut(options, ","); option == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isQuotable takes the type of a struct field and returns whether
+// `encoding/json` encodes the field's value within a JSON string when the
+// field has the "string" tag option, as it only does for scalar values.
+func isQuotable(t reflect.Type) bool {
+	if "" == t.Name() && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String:
+		return true
+	}
+
+	return isNumber(t.Kind())
+}
+
+// isEmptyValue takes a value and returns whether `encoding/json` considers the
+// value empty, and therefore omits it from a field with the "omitempty" tag
+// option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return 0 == v.Len()
+	case reflect.Bool, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+
+	return isNumber(v.Kind()) && v.IsZero()
+}
+
+// lookupField takes a list of fields and a JSON object key and returns the
+// field that `encoding/json` would decode the key's value into, preferring an
+// exact match of the field's name and otherwise matching case-insensitively.
+func lookupField(fields []field, key string) (field, bool) {
+	for _, f := range fields {
+		if key == f.name {
+			return f, true
+		}
+	}
+
+	for _, f := range fields {
+		if strings.EqualFold(key, f.name) {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
+
+// fieldByIndex takes a struct value and a field index and returns the nested
+// field's value, or an invalid value if the field is only reachable through a
+// nil embedded struct pointer.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	field, err := v.FieldByIndexErr(index)
+
+	if nil != err {
+		return reflect.Value{}
+	}
+
+	return field
+}
+
+// mapKeyString takes a map key value and returns the JSON object key that
+// `encoding/json` would encode it as.
+func mapKeyString(key reflect.Value) (string, bool) {
+	if key.Kind() == reflect.String {
+		return key.String(), true
+	}
+
+	if key.Type().Implements(textMarshalerType) {
+		if key.Kind() == reflect.Pointer && key.IsNil() {
+			return "", true
+		}
+
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+
+		return string(text), nil == err
+	}
+
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), true
+	}
+
+	return "", false
+}
+
+// implements takes a type and an interface type and returns whether the type,
+// or a pointer to the type, implements the interface.
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// implementsEither takes a type and two interface types and returns whether
+// the type, or a pointer to the type, implements either interface.
+func implementsEither(t reflect.Type, first, second reflect.Type) bool {
+	return implements(t, first) || implements(t, second)
+}
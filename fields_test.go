@@ -0,0 +1,91 @@
+package conjson
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type embeddedFields struct {
+	Embedded   string
+	Shadowed   string
+	Conflicted string
+}
+
+type otherEmbeddedFields struct {
+	Conflicted string
+}
+
+type fieldsModel struct {
+	embeddedFields
+	*otherEmbeddedFields
+	Named    string `json:"renamed,omitempty"`
+	Skipped  string `json:"-"`
+	Shadowed int
+	Time     time.Time `json:",omitempty"`
+	private  string
+}
+
+func TestTypeFields(t *testing.T) {
+	fields := typeFields(reflect.TypeOf(fieldsModel{}))
+
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+
+	if expected := []string{"Embedded", "renamed", "Shadowed", "Time"}; !reflect.DeepEqual(expected, names) {
+		t.Errorf("names were `%v`, when expected to be `%v`", names, expected)
+	}
+
+	if f, _ := lookupField(fields, "Embedded"); !reflect.DeepEqual([]int{0, 0}, f.index) {
+		t.Errorf("index of promoted field was `%v`, when expected to be `%v`", f.index, []int{0, 0})
+	}
+
+	if f, _ := lookupField(fields, "Shadowed"); reflect.TypeOf(0) != f.typ {
+		t.Errorf("type of shadowing field was `%v`, when expected to be `%v`", f.typ, reflect.TypeOf(0))
+	}
+
+	// The cached list should be returned on subsequent calls
+	if again := typeFields(reflect.TypeOf(fieldsModel{})); &fields[0] != &again[0] {
+		t.Error("typeFields didn't return the cached list of fields")
+	}
+}
+
+func TestLookupField(t *testing.T) {
+	fields := []field{{name: "ImageURL"}, {name: "imageurl"}, {name: "Title"}}
+
+	for _, testCase := range []struct {
+		key          string
+		expectedName string
+		expectFound  bool
+	}{
+		{"ImageURL", "ImageURL", true},
+		{"imageurl", "imageurl", true},
+		{"imageUrl", "ImageURL", true},
+		{"TITLE", "Title", true},
+		{"Description", "", false},
+	} {
+		if f, found := lookupField(fields, testCase.key); testCase.expectFound != found || testCase.expectedName != f.name {
+			t.Errorf("lookupField(%q) was %q (%t), when expected to be %q (%t)", testCase.key, f.name, found, testCase.expectedName, testCase.expectFound)
+		}
+	}
+}
+
+func TestMapKeyString(t *testing.T) {
+	for _, testCase := range []struct {
+		key           interface{}
+		expectedKey   string
+		expectedValid bool
+	}{
+		{"key", "key", true},
+		{-12, "-12", true},
+		{uint8(12), "12", true},
+		{time.Date(2018, 12, 24, 0, 0, 0, 0, time.UTC), "2018-12-24T00:00:00Z", true},
+		{1.5, "", false},
+	} {
+		if key, isValid := mapKeyString(reflect.ValueOf(testCase.key)); testCase.expectedKey != key || testCase.expectedValid != isValid {
+			t.Errorf("mapKeyString(%v) was %q (%t), when expected to be %q (%t)", testCase.key, key, isValid, testCase.expectedKey, testCase.expectedValid)
+		}
+	}
+}
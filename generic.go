@@ -0,0 +1,82 @@
+package conjson
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+// Value is a generic wrapper of a value that runs the transformers of the
+// convention C upon JSON marshaling and unmarshaling of only the wrapped value.
+//
+// Value is most useful as the type of a field of a struct, so that the
+// convention is only applied to that field's JSON, rather than to the JSON of
+// the entire struct. As the convention is part of the type, a zero Value is
+// ready to be unmarshaled into.
+//
+// The transformers of the convention are those returned by the
+// JSONTransformers method of the zero value of C, so C should be a non-pointer
+// type, such as an empty struct.
+//
+// The keys of the JSON of a Value are left untouched by the transformers of any
+// value that it's nested within, when marshaled or unmarshaled by this
+// package, though those transformers still see the JSON, so that transformers
+// of values, such as transform.Redact, apply within it too.
+type Value[T any, C ConventionProvider] struct {
+	Value T
+}
+
+// NewValue takes a value and returns a Value that wraps the given value with
+// the convention C.
+func NewValue[C ConventionProvider, T any](value T) Value[T, C] {
+	return Value[T, C]{value}
+}
+
+// Marshal takes a value and a variable number of `transform.Transformer`s and
+// returns the JSON encoding of the value, with the given transformers having
+// run on the output.
+//
+// As with `Codec.Marshal`, the output is returned exactly as the transformers
+// produced it.
+//
+// See the documentation for `encoding/json.Marshal` for more details.
+func Marshal[T any](value T, transformers ...transform.Transformer) ([]byte, error) {
+	return marshalJSON(&marshaler{context.Background(), value, newCodec(ignoreContext(transformers)), nil})
+}
+
+// Unmarshal takes JSON encoded data and a variable number of
+// `transform.Transformer`s and returns the result of decoding the data into a
+// value of the type T, with the given transformers having run on the input.
+//
+// See the documentation for `encoding/json.Unmarshal` for more details.
+func Unmarshal[T any](data []byte, transformers ...transform.Transformer) (T, error) {
+	var value T
+
+	err := json.Unmarshal(data, NewUnmarshaler(&value, transformers...))
+
+	return value, err
+}
+
+// isolatedJSON marks a Value as running its own transformations.
+func (v Value[T, C]) isolatedJSON() {}
+
+// transformers returns the transformers of the Value's convention.
+func (v Value[T, C]) transformers() []transform.Transformer {
+	var convention C
+
+	return convention.JSONTransformers()
+}
+
+// MarshalJSON satisfies the `encoding/json.Marshaler` interface to marshal the
+// wrapped value with the transformers of the Value's convention.
+func (v Value[T, C]) MarshalJSON() ([]byte, error) {
+	return NewMarshaler(v.Value, v.transformers()...).MarshalJSON()
+}
+
+// UnmarshalJSON satisfies the `encoding/json.Unmarshaler` interface to
+// unmarshal into the wrapped value with the transformers of the Value's
+// convention.
+func (v *Value[T, C]) UnmarshalJSON(data []byte) error {
+	return NewUnmarshaler(&v.Value, v.transformers()...).UnmarshalJSON(data)
+}
@@ -0,0 +1,150 @@
+package conjson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+var (
+	// Compile time interface assertion
+	_ json.Marshaler   = Value[bool, conventionalKeys]{}
+	_ json.Unmarshaler = (*Value[bool, conventionalKeys])(nil)
+)
+
+type genericModel struct {
+	ImageURL string
+	Vendor   Value[codecModel, conventionalKeys]
+}
+
+type conventionalKeys struct{}
+
+type camelCaseKeys struct{}
+
+func (conventionalKeys) JSONTransformers() []transform.Transformer {
+	return []transform.Transformer{transform.ConventionalKeys()}
+}
+
+func (camelCaseKeys) JSONTransformers() []transform.Transformer {
+	return []transform.Transformer{transform.CamelCaseKeys(false)}
+}
+
+func TestNewValue(t *testing.T) {
+	if value := NewValue[conventionalKeys](testCodecModel); true {
+		if testCodecModel != value.Value {
+			t.Errorf("value.Value was `%v`, when expected to be `%v`", value.Value, testCodecModel)
+		}
+
+		if 1 != len(value.transformers()) {
+			t.Errorf("value.transformers() had `%d` transformers, when expected to have `1`", len(value.transformers()))
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	if output, err := Marshal(testCodecModel, transform.ConventionalKeys()); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if codecModelConventionalJSON != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, codecModelConventionalJSON)
+		}
+	}
+
+	shouldError := errorMarshaler(true)
+	if _, err := Marshal(&shouldError); nil == err {
+		t.Error("Expected error was nil")
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	if model, err := Unmarshal[codecModel]([]byte(codecModelConventionalJSON), transform.ConventionalKeys()); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if testCodecModel != model {
+			t.Errorf("model was `%v`, when expected to be `%v`", model, testCodecModel)
+		}
+	}
+
+	if model, err := Unmarshal[*codecModel]([]byte(codecModelConventionalJSON), transform.ConventionalKeys()); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if nil == model || testCodecModel != *model {
+			t.Errorf("model was `%v`, when expected to be `%v`", model, &testCodecModel)
+		}
+	}
+
+	if _, err := Unmarshal[codecModel]([]byte(`"not an object"`)); nil == err {
+		t.Error("Expected error was nil")
+	}
+}
+
+func TestValue_MarshalJSON(t *testing.T) {
+	model := genericModel{
+		ImageURL: "https://example.com/outer.png",
+		Vendor:   NewValue[conventionalKeys](testCodecModel),
+	}
+
+	expected := `{"ImageURL":"https://example.com/outer.png","Vendor":` + codecModelConventionalJSON + `}`
+
+	if output, err := json.Marshal(model); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, expected)
+		}
+	}
+
+	shouldError := errorMarshaler(true)
+	if _, err := json.Marshal(NewValue[conventionalKeys](&shouldError)); nil == err {
+		t.Error("Expected error was nil")
+	}
+}
+
+func TestValue_UnmarshalJSON(t *testing.T) {
+	input := `{"ImageURL":"https://example.com/outer.png","Vendor":` + codecModelConventionalJSON + `}`
+
+	// A zero Value is unmarshaled with its convention
+	model := genericModel{}
+
+	if err := json.Unmarshal([]byte(input), &model); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "https://example.com/outer.png"; expected != model.ImageURL {
+			t.Errorf("model.ImageURL was %q, when expected to be %q", model.ImageURL, expected)
+		}
+
+		if testCodecModel != model.Vendor.Value {
+			t.Errorf("model.Vendor.Value was `%v`, when expected to be `%v`", model.Vendor.Value, testCodecModel)
+		}
+	}
+
+	// A Value nested within a value with another convention keeps its own
+	type fooModel struct {
+		FooBar string
+	}
+
+	type outerModel struct {
+		Inner Value[fooModel, conventionalKeys]
+	}
+
+	if model, err := Unmarshal[outerModel]([]byte(`{"inner":{"foo_bar":"y"}}`), transform.CamelCaseKeys(false)); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if "y" != model.Inner.Value.FooBar {
+			t.Errorf("model.Inner.Value.FooBar was %q, when expected to be %q", model.Inner.Value.FooBar, "y")
+		}
+	}
+}
@@ -1 +1,3 @@
 module github.com/Rican7/conjson
+
+go 1.23
@@ -0,0 +1,295 @@
+// Package jsontree provides a minimal, position-aware, parsed representation
+// of JSON data, so that the structure of a JSON document may be related back to
+// the bytes of its source.
+//
+// Copyright © Trevor N. Suarez (Rican7)
+package jsontree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Kind defines the kind of a JSON value.
+type Kind uint8
+
+const (
+	// Null defines the kind of a JSON null literal.
+	Null Kind = iota
+
+	// Bool defines the kind of a JSON boolean literal.
+	Bool
+
+	// Number defines the kind of a JSON number.
+	Number
+
+	// String defines the kind of a JSON string.
+	String
+
+	// Array defines the kind of a JSON array.
+	Array
+
+	// Object defines the kind of a JSON object.
+	Object
+)
+
+// Node is a structure defining a JSON value and its position within the source
+// data that it was parsed from.
+type Node struct {
+	Kind Kind
+
+	// Start and End are the offsets of the first byte of the value and of the
+	// byte following the value, respectively, within the source data.
+	Start, End int
+
+	// Members are the members of an Object, in source order.
+	Members []Member
+
+	// Elements are the elements of an Array, in source order.
+	Elements []*Node
+}
+
+// Member is a structure defining a single name/value pair of a JSON object.
+type Member struct {
+	// Key is the decoded (unquoted and unescaped) name of the member.
+	Key string
+
+	// KeyStart and KeyEnd are the offsets of the opening quote of the
+	// member's name and of the byte following its closing quote, respectively,
+	// within the source data.
+	KeyStart, KeyEnd int
+
+	Value *Node
+}
+
+// SyntaxError is an error describing a JSON syntax error, and its position.
+type SyntaxError struct {
+	msg    string
+	Offset int
+}
+
+// parser is a structure that holds the state of a parse of JSON data.
+type parser struct {
+	data []byte
+	pos  int
+}
+
+// Parse takes JSON data and returns the root Node of the parsed data, or an
+// error if the data isn't a single, syntactically valid, JSON value.
+func Parse(data []byte) (*Node, error) {
+	p := &parser{data: data}
+
+	node, err := p.value()
+
+	if nil == err {
+		if p.skipSpace(); p.pos < len(p.data) {
+			err = p.errorf("invalid character %q after top-level value", p.data[p.pos])
+		}
+	}
+
+	return node, err
+}
+
+// Error satisfies the error interface to describe the syntax error.
+func (e *SyntaxError) Error() string {
+	return e.msg
+}
+
+// Raw takes the source data that the Node was parsed from and returns the raw
+// bytes of the Node's value.
+func (n *Node) Raw(data []byte) []byte {
+	return data[n.Start:n.End]
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{"jsontree: " + fmt.Sprintf(format, args...), p.pos}
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) value() (*Node, error) {
+	if p.skipSpace(); p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of JSON input")
+	}
+
+	switch c := p.data[p.pos]; {
+	case '{' == c:
+		return p.object()
+	case '[' == c:
+		return p.array()
+	case '"' == c:
+		start := p.pos
+		err := p.string()
+
+		return &Node{Kind: String, Start: start, End: p.pos}, err
+	case '-' == c || ('0' <= c && '9' >= c):
+		return p.number()
+	default:
+		return p.literal()
+	}
+}
+
+func (p *parser) object() (*Node, error) {
+	node := &Node{Kind: Object, Start: p.pos}
+	p.pos++
+
+	if p.skipSpace(); p.pos < len(p.data) && '}' == p.data[p.pos] {
+		p.pos++
+		node.End = p.pos
+
+		return node, nil
+	}
+
+	for {
+		if p.skipSpace(); p.pos >= len(p.data) || '"' != p.data[p.pos] {
+			return nil, p.errorf("expected object key")
+		}
+
+		member := Member{KeyStart: p.pos}
+
+		if err := p.string(); nil != err {
+			return nil, err
+		}
+
+		member.KeyEnd = p.pos
+
+		if key := p.data[member.KeyStart+1 : member.KeyEnd-1]; !bytes.ContainsRune(key, '\\') {
+			member.Key = string(key)
+		} else if err := json.Unmarshal(p.data[member.KeyStart:member.KeyEnd], &member.Key); nil != err {
+			return nil, p.errorf("invalid object key: %v", err)
+		}
+
+		if p.skipSpace(); p.pos >= len(p.data) || ':' != p.data[p.pos] {
+			return nil, p.errorf("expected ':' after object key")
+		}
+
+		p.pos++
+
+		value, err := p.value()
+
+		if nil != err {
+			return nil, err
+		}
+
+		member.Value = value
+		node.Members = append(node.Members, member)
+
+		if done, err := p.next('}'); nil != err || done {
+			node.End = p.pos
+
+			return node, err
+		}
+	}
+}
+
+func (p *parser) array() (*Node, error) {
+	node := &Node{Kind: Array, Start: p.pos}
+	p.pos++
+
+	if p.skipSpace(); p.pos < len(p.data) && ']' == p.data[p.pos] {
+		p.pos++
+		node.End = p.pos
+
+		return node, nil
+	}
+
+	for {
+		element, err := p.value()
+
+		if nil != err {
+			return nil, err
+		}
+
+		node.Elements = append(node.Elements, element)
+
+		if done, err := p.next(']'); nil != err || done {
+			node.End = p.pos
+
+			return node, err
+		}
+	}
+}
+
+// next consumes the separator following a member or element, and returns
+// whether the separator was the given closing delimiter.
+func (p *parser) next(closing byte) (bool, error) {
+	if p.skipSpace(); p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+
+			return false, nil
+		case closing:
+			p.pos++
+
+			return true, nil
+		}
+	}
+
+	return false, p.errorf("expected ',' or '%c'", closing)
+}
+
+func (p *parser) string() error {
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos++
+		case '"':
+			p.pos++
+
+			return nil
+		}
+	}
+
+	return p.errorf("unexpected end of JSON input in string")
+}
+
+func (p *parser) number() (*Node, error) {
+	node := &Node{Kind: Number, Start: p.pos}
+
+	for ; p.pos < len(p.data); p.pos++ {
+		switch c := p.data[p.pos]; {
+		case '-' == c || '+' == c || '.' == c || 'e' == c || 'E' == c:
+		case '0' <= c && '9' >= c:
+		default:
+			node.End = p.pos
+
+			return node, p.validNumber(node)
+		}
+	}
+
+	node.End = p.pos
+
+	return node, p.validNumber(node)
+}
+
+func (p *parser) validNumber(node *Node) error {
+	if !json.Valid(p.data[node.Start:node.End]) {
+		return p.errorf("invalid number literal %q", p.data[node.Start:node.End])
+	}
+
+	return nil
+}
+
+func (p *parser) literal() (*Node, error) {
+	for literal, kind := range map[string]Kind{"true": Bool, "false": Bool, "null": Null} {
+		if end := p.pos + len(literal); end <= len(p.data) && literal == string(p.data[p.pos:end]) {
+			node := &Node{Kind: kind, Start: p.pos, End: end}
+			p.pos = end
+
+			return node, nil
+		}
+	}
+
+	return nil, p.errorf("invalid character %q looking for beginning of value", p.data[p.pos])
+}
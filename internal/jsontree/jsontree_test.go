@@ -0,0 +1,70 @@
+package jsontree
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const testJSON = ` {"a": [1, -2.5e3, "x\"y"], "bc": {"d": null, "e": true}, "f": {}, "g": []} `
+
+	root, err := Parse([]byte(testJSON))
+
+	if nil != err {
+		t.Fatalf("Unexpected error (%T) %q", err, err)
+	}
+
+	if Object != root.Kind || `{"a": [1, -2.5e3, "x\"y"], "bc": {"d": null, "e": true}, "f": {}, "g": []}` != string(root.Raw([]byte(testJSON))) {
+		t.Errorf("root was (%d) %s", root.Kind, root.Raw([]byte(testJSON)))
+	}
+
+	for _, testCase := range []struct {
+		node        *Node
+		expectedRaw string
+		kind        Kind
+	}{
+		{root.Members[0].Value, `[1, -2.5e3, "x\"y"]`, Array},
+		{root.Members[0].Value.Elements[0], `1`, Number},
+		{root.Members[0].Value.Elements[1], `-2.5e3`, Number},
+		{root.Members[0].Value.Elements[2], `"x\"y"`, String},
+		{root.Members[1].Value, `{"d": null, "e": true}`, Object},
+		{root.Members[1].Value.Members[0].Value, `null`, Null},
+		{root.Members[1].Value.Members[1].Value, `true`, Bool},
+		{root.Members[2].Value, `{}`, Object},
+		{root.Members[3].Value, `[]`, Array},
+	} {
+		if raw := string(testCase.node.Raw([]byte(testJSON))); testCase.expectedRaw != raw || testCase.kind != testCase.node.Kind {
+			t.Errorf("node was (%d) %s, when expected to be (%d) %s", testCase.node.Kind, raw, testCase.kind, testCase.expectedRaw)
+		}
+	}
+
+	for i, expectedKey := range []string{"a", "bc", "f", "g"} {
+		if member := root.Members[i]; expectedKey != member.Key {
+			t.Errorf("Key was %q, when expected to be %q", member.Key, expectedKey)
+		}
+	}
+
+	if member := root.Members[1]; `"bc"` != testJSON[member.KeyStart:member.KeyEnd] {
+		t.Errorf("Raw key was %s, when expected to be %s", testJSON[member.KeyStart:member.KeyEnd], `"bc"`)
+	}
+}
+
+func TestParse_InvalidJSON(t *testing.T) {
+	for _, invalidJSON := range []string{
+		``,
+		`{`,
+		`{"a"}`,
+		`{"a": 1,}`,
+		`{a: 1}`,
+		`[1 2]`,
+		`"unterminated`,
+		`tru`,
+		`1.2.3`,
+		`{} {}`,
+	} {
+		if _, err := Parse([]byte(invalidJSON)); nil == err {
+			t.Errorf("Expected error was nil for %q", invalidJSON)
+		} else if _, isSyntaxError := err.(*SyntaxError); !isSyntaxError {
+			t.Errorf("Error (%T) %q isn't a *SyntaxError", err, err)
+		}
+	}
+}
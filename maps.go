@@ -0,0 +1,528 @@
+package conjson
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+// mapper is a structure that holds the state of a conversion between a Go
+// value and its representation as a map, with the keys of the map in the same
+// convention as the keys of the value's transformed JSON.
+type mapper struct {
+	*walker
+}
+
+// ToMap takes a value and a variable number of `transform.Transformer`s and
+// returns the value as a map, with the same keys as the value's JSON encoding
+// would have once the given transformers have run on it.
+//
+// The value is converted through reflection, rather than by encoding it to
+// JSON and decoding it again. The keys are derived by running the transformers
+// on documents that contain only a field's name or a map's key, such as
+// `{"ImageURL":true}`, which are cached by the Codec. Structs and maps are converted to maps, and
+// slices and arrays (other than byte slices) are converted to
+// `[]interface{}`s, while all other values, including those that encode their
+// own JSON, are kept as they are. The "omitempty" and "string" options of
+// struct tags are honored, so that empty fields are omitted and quoted fields
+// are kept as the strings that they're encoded as.
+//
+// If the value isn't represented as a JSON object, ErrNotObject is returned.
+func ToMap(value interface{}, transformers ...transform.Transformer) (map[string]interface{}, error) {
+	return newCodec(ignoreContext(transformers)).ToMap(value)
+}
+
+// FromMap takes a map, a pointer value, and a variable number of
+// `transform.Transformer`s and stores the map's values in the pointed to
+// value, matching the map's keys to the fields of structs the same as the keys
+// of JSON data would be matched once the given transformers have run on it.
+//
+// The map is converted through reflection, rather than by encoding it to JSON
+// and decoding it again, with the keys matched the same as they are for ToMap.
+// Maps may be stored in structs and maps, slices may be
+// stored in slices and arrays, and strings may be stored in types that decode
+// themselves from text, while all other values are stored when they're
+// assignable to the target, or are numbers that convert to the target without
+// loss.
+func FromMap(m map[string]interface{}, value interface{}, transformers ...transform.Transformer) error {
+	return newCodec(ignoreContext(transformers)).FromMap(m, value)
+}
+
+// ToMap takes a value and returns the value as a map, with the same keys as
+// the value's JSON encoding would have once the codec's transformers have run
+// on it.
+//
+// See the documentation for the package-level ToMap for more details.
+func (c *Codec) ToMap(value interface{}) (map[string]interface{}, error) {
+	m := newMapper(c, transform.Marshal)
+
+	converted, err := m.toValue(reflect.ValueOf(value), m.root())
+
+	if nil != err {
+		return nil, err
+	}
+
+	asMap, isMap := converted.(map[string]interface{})
+
+	if !isMap {
+		return nil, ErrNotObject
+	}
+
+	return asMap, nil
+}
+
+// FromMap takes a map and a pointer value and stores the map's values in the
+// pointed to value, matching the map's keys to the fields of structs the same
+// as the keys of JSON data would be matched once the codec's transformers have
+// run on it.
+//
+// Keys that don't map to any field are rejected or reported as configured
+// with DisallowUnknownFields or WithUnknownFieldHandler, but only once the
+// rest of the map has been stored.
+//
+// See the documentation for the package-level FromMap for more details.
+func (c *Codec) FromMap(source map[string]interface{}, value interface{}) error {
+	target := reflect.ValueOf(value)
+
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(value)}
+	}
+
+	m := newMapper(c, transform.Unmarshal)
+
+	if err := m.assign(source, target.Elem(), m.root()); nil != err {
+		return err
+	}
+
+	if 0 < len(m.unknownFields) {
+		if nil != c.unknownFieldHandler {
+			for _, path := range m.unknownFields {
+				c.unknownFieldHandler(path)
+			}
+		}
+
+		if c.disallowUnknownFields {
+			return &UnknownFieldsError{Paths: m.unknownFields}
+		}
+	}
+
+	return nil
+}
+
+// newMapper takes a codec and a direction and returns a new mapper.
+func newMapper(c *Codec, direction transform.Direction) *mapper {
+	return &mapper{&walker{ctx: context.Background(), codec: c, direction: direction}}
+}
+
+// toValue takes a Go value and the subtree that the value is within and
+// returns the value as it's represented in a map.
+func (m *mapper) toValue(v reflect.Value, within *subtree) (interface{}, error) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		within = m.within(v.Type(), within)
+
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	t := v.Type()
+	within = m.within(t, within)
+
+	// Values that encode themselves are kept as they are
+	if implementsEither(t, marshalerType, textMarshalerType) {
+		return v.Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		converted := make(map[string]interface{})
+		var extras Extras
+
+		for _, f := range typeFields(t) {
+			fieldValue := fieldByIndex(v, f.index)
+
+			if !fieldValue.IsValid() || (f.omitEmpty && isEmptyValue(fieldValue)) {
+				continue
+			}
+
+			if extrasType == f.typ {
+				extras = fieldValue.Interface().(Extras)
+
+				continue
+			}
+
+			fieldConverted, err := m.toValue(fieldValue, within)
+
+			if nil != err {
+				return nil, err
+			}
+
+			// Quoted values are kept as the JSON strings they're encoded as
+			if f.quoted && nil != fieldConverted {
+				encoded, err := json.Marshal(fieldConverted)
+
+				if nil != err {
+					return nil, err
+				}
+
+				fieldConverted = string(encoded)
+			}
+
+			converted[m.key(f.name, within)] = fieldConverted
+		}
+
+		// Extra members are kept untransformed, and never replace fields
+		for key, extra := range extras {
+			if _, isField := converted[key]; !isField {
+				converted[key] = extra
+			}
+		}
+
+		return converted, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		converted := make(map[string]interface{}, v.Len())
+
+		for iter := v.MapRange(); iter.Next(); {
+			key, isValid := mapKeyString(iter.Key())
+
+			if !isValid {
+				return nil, &json.UnsupportedTypeError{Type: t}
+			}
+
+			elementConverted, err := m.toValue(iter.Value(), within)
+
+			if nil != err {
+				return nil, err
+			}
+
+			converted[m.key(key, within)] = elementConverted
+		}
+
+		return converted, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || t.Elem().Kind() == reflect.Uint8) {
+			return v.Interface(), nil
+		}
+
+		converted := make([]interface{}, v.Len())
+
+		for i := range converted {
+			elementConverted, err := m.toValue(v.Index(i), within)
+
+			if nil != err {
+				return nil, err
+			}
+
+			converted[i] = elementConverted
+		}
+
+		return converted, nil
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return nil, &json.UnsupportedTypeError{Type: t}
+	}
+
+	return v.Interface(), nil
+}
+
+// assign takes a value from a map, a settable Go value, and the subtree that
+// the Go value is within, and stores the map's value in the Go value.
+func (m *mapper) assign(source interface{}, target reflect.Value, within *subtree) error {
+	within = m.within(target.Type(), within)
+
+	// Like a JSON null, nil only resets the values that may be nil
+	if nil == source {
+		switch target.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			target.SetZero()
+		}
+
+		return nil
+	}
+
+	sourceValue := reflect.ValueOf(source)
+
+	switch {
+	case target.Kind() == reflect.Pointer && !sourceValue.Type().AssignableTo(target.Type()):
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+
+		return m.assign(source, target.Elem(), within)
+	case target.Kind() == reflect.Interface && 0 == target.NumMethod():
+		target.Set(reflect.ValueOf(m.plainValue(source, within)))
+
+		return nil
+	}
+
+	if source, isMap := source.(map[string]interface{}); isMap {
+		switch target.Kind() {
+		case reflect.Struct:
+			return m.assignStruct(source, target, within)
+		case reflect.Map:
+			return m.assignMap(source, target, within)
+		}
+	}
+
+	isSequence := sourceValue.Kind() == reflect.Slice || sourceValue.Kind() == reflect.Array
+
+	if isSequence && (target.Kind() == reflect.Slice || target.Kind() == reflect.Array) && !sourceValue.Type().AssignableTo(target.Type()) {
+		return m.assignSequence(sourceValue, target, within)
+	}
+
+	switch {
+	case sourceValue.Type().AssignableTo(target.Type()):
+		target.Set(sourceValue)
+
+		return nil
+	case isNumber(sourceValue.Kind()) && isNumber(target.Kind()):
+		converted := sourceValue.Convert(target.Type())
+
+		// Only numbers that convert without loss are stored
+		if converted.Convert(sourceValue.Type()).Equal(sourceValue) {
+			target.Set(converted)
+
+			return nil
+		}
+	case sourceValue.Kind() == reflect.String && target.Addr().Type().Implements(textUnmarshalerType):
+		return target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(sourceValue.String()))
+	case sourceValue.Kind() == reflect.String && target.Kind() == reflect.String:
+		target.SetString(sourceValue.String())
+
+		return nil
+	}
+
+	return &json.UnmarshalTypeError{
+		Value: sourceValue.Kind().String(),
+		Type:  target.Type(),
+		Field: strings.Join(m.path, "."),
+	}
+}
+
+// assignStruct takes a map, a settable struct value, and the subtree that the
+// struct is within, and stores the map's values in the struct's fields.
+func (m *mapper) assignStruct(source map[string]interface{}, target reflect.Value, within *subtree) error {
+	fields := typeFields(target.Type())
+	extras, hasExtras := extrasField(fields)
+
+	for _, key := range sortedKeys(source) {
+		m.path = append(m.path, key)
+
+		if f, found := m.lookupField(fields, key, within); found && extrasType != f.typ {
+			if err := m.assignField(f, source[key], allocateField(target, f.index), within); nil != err {
+				return err
+			}
+		} else if hasExtras {
+			// Extra members are kept untransformed, as their JSON
+			encoded, err := json.Marshal(source[key])
+
+			if nil != err {
+				return err
+			}
+
+			extrasValue := allocateField(target, extras.index)
+
+			if extrasValue.IsNil() {
+				extrasValue.Set(reflect.ValueOf(Extras{}))
+			}
+
+			extrasValue.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(json.RawMessage(encoded)))
+		} else {
+			m.unknownFields = append(m.unknownFields, strings.Join(m.path, "."))
+		}
+
+		m.path = m.path[:len(m.path)-1]
+	}
+
+	return nil
+}
+
+// assignField takes a struct field, a value from a map, the field's settable
+// Go value, and the subtree that the struct is within, and stores the map's
+// value in the field, decoding the JSON within a quoted field's string.
+func (m *mapper) assignField(f field, source interface{}, target reflect.Value, within *subtree) error {
+	quoted, isString := source.(string)
+
+	if !f.quoted || !isString {
+		return m.assign(source, target, within)
+	}
+
+	if err := json.Unmarshal([]byte(quoted), target.Addr().Interface()); nil != err {
+		return &json.UnmarshalTypeError{Value: "string " + strconv.Quote(quoted), Type: f.typ, Field: strings.Join(m.path, ".")}
+	}
+
+	return nil
+}
+
+// assignMap takes a map, a settable map value, and the subtree that the map
+// value is within, and stores the map's entries in the map value.
+func (m *mapper) assignMap(source map[string]interface{}, target reflect.Value, within *subtree) error {
+	t := target.Type()
+
+	if target.IsNil() {
+		target.Set(reflect.MakeMapWithSize(t, len(source)))
+	}
+
+	for _, key := range sortedKeys(source) {
+		m.path = append(m.path, key)
+
+		mapKey, isValid := mapKeyValue(m.key(key, within), t.Key())
+
+		if !isValid {
+			return &json.UnmarshalTypeError{Value: "string " + strconv.Quote(key), Type: t.Key(), Field: strings.Join(m.path, ".")}
+		}
+
+		element := reflect.New(t.Elem()).Elem()
+
+		if err := m.assign(source[key], element, within); nil != err {
+			return err
+		}
+
+		target.SetMapIndex(mapKey, element)
+		m.path = m.path[:len(m.path)-1]
+	}
+
+	return nil
+}
+
+// assignSequence takes a slice or array, a settable slice or array value, and
+// the subtree that the value is within, and stores the elements in the value.
+func (m *mapper) assignSequence(source reflect.Value, target reflect.Value, within *subtree) error {
+	length := source.Len()
+
+	if target.Kind() == reflect.Slice {
+		target.Set(reflect.MakeSlice(target.Type(), length, length))
+	} else {
+		// Like decoding JSON, extra elements are dropped and missing elements
+		// are zeroed
+		target.SetZero()
+		length = min(length, target.Len())
+	}
+
+	for i := 0; i < length; i++ {
+		m.path = append(m.path, strconv.Itoa(i))
+
+		if err := m.assign(source.Index(i).Interface(), target.Index(i), within); nil != err {
+			return err
+		}
+
+		m.path = m.path[:len(m.path)-1]
+	}
+
+	return nil
+}
+
+// plainValue takes a value from a map and the subtree that the value is within
+// and returns the value with the keys of any nested maps transformed, as the
+// value would be decoded into an `interface{}`.
+func (m *mapper) plainValue(source interface{}, within *subtree) interface{} {
+	switch source := source.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(source))
+
+		for key, value := range source {
+			converted[m.key(key, within)] = m.plainValue(value, within)
+		}
+
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(source))
+
+		for i, value := range source {
+			converted[i] = m.plainValue(value, within)
+		}
+
+		return converted
+	}
+
+	return source
+}
+
+// allocateField takes a settable struct value and a field index and returns
+// the nested field's value, allocating any nil embedded struct pointers that
+// the field is reached through.
+func allocateField(v reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if 0 < i && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(fieldIndex)
+	}
+
+	return v
+}
+
+// mapKeyValue takes a JSON object key and a map key type and returns the map
+// key that `encoding/json` would decode the JSON object key as.
+func mapKeyValue(key string, t reflect.Type) (reflect.Value, bool) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		mapKey := reflect.New(t)
+
+		if err := mapKey.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); nil != err {
+			return reflect.Value{}, false
+		}
+
+		return mapKey.Elem(), true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(key).Convert(t), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(key, 10, t.Bits())
+
+		return reflect.ValueOf(parsed).Convert(t), nil == err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		parsed, err := strconv.ParseUint(key, 10, t.Bits())
+
+		return reflect.ValueOf(parsed).Convert(t), nil == err
+	}
+
+	return reflect.Value{}, false
+}
+
+// isNumber takes a kind and returns whether it's a kind of number.
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// sortedKeys takes a map and returns its keys, sorted, so that maps are
+// always walked in the same order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
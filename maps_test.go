@@ -0,0 +1,236 @@
+package conjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+type mapsOwnerModel struct {
+	EmailAddress string
+}
+
+type mapsModel struct {
+	ImageURL  string
+	Count     int
+	Owner     *mapsOwnerModel
+	Tags      []string
+	ByKey     map[string]int
+	CreatedAt time.Time
+	Dynamic   interface{}
+	Data      []byte
+}
+
+func TestToMap(t *testing.T) {
+	createdAt := time.Date(2015, 11, 17, 20, 43, 31, 0, time.UTC)
+
+	value := mapsModel{
+		ImageURL:  "a",
+		Count:     2,
+		Owner:     &mapsOwnerModel{"b@example.com"},
+		Tags:      []string{"c"},
+		ByKey:     map[string]int{"someKey": 3},
+		CreatedAt: createdAt,
+		Dynamic:   map[string]interface{}{"innerKey": 4},
+		Data:      []byte("d"),
+	}
+
+	converted, err := ToMap(&value, transform.ConventionalKeys())
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expected := map[string]interface{}{
+		"image_url":  "a",
+		"count":      2,
+		"owner":      map[string]interface{}{"email_address": "b@example.com"},
+		"tags":       []interface{}{"c"},
+		"by_key":     map[string]interface{}{"some_key": 3},
+		"created_at": createdAt,
+		"dynamic":    map[string]interface{}{"inner_key": 4},
+		"data":       []byte("d"),
+	}
+
+	if !reflect.DeepEqual(expected, converted) {
+		t.Errorf("converted was `%#v`, when expected to be `%#v`", converted, expected)
+	}
+
+	var decoded mapsModel
+	if err := FromMap(converted, &decoded, transform.ConventionalKeys()); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if !reflect.DeepEqual(value, decoded) {
+		t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, value)
+	}
+}
+
+func TestToMap_AssertNonObjectsFail(t *testing.T) {
+	for _, value := range []interface{}{nil, 1, "string", []int{1}, (*mapsModel)(nil)} {
+		if converted, err := ToMap(value); ErrNotObject != err || nil != converted {
+			t.Errorf("ToMap(%#v) returned `%v` (%v), when expected to return ErrNotObject", value, converted, err)
+		}
+	}
+
+	if _, err := ToMap(map[string]interface{}{"key": make(chan int)}); nil == err {
+		t.Error("ToMap was expected to fail with an unsupported type")
+	}
+}
+
+func TestToMap_AssertTagOptionsAreHonored(t *testing.T) {
+	type model struct {
+		ID       int      `json:",string"`
+		ImageURL string   `json:",omitempty"`
+		Title    string   `json:",omitempty,string"`
+		Rating   *float64 `json:",string,omitempty"`
+		Tags     []string `json:",omitempty"`
+		IsActive bool     `json:",string"`
+	}
+
+	rating := 4.5
+	value := model{ID: 5, Title: "a", Rating: &rating}
+
+	converted, err := ToMap(value, transform.ConventionalKeys())
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expected := map[string]interface{}{"id": "5", "title": `"a"`, "rating": "4.5", "is_active": "false"}
+
+	if !reflect.DeepEqual(expected, converted) {
+		t.Errorf("converted was `%#v`, when expected to be `%#v`", converted, expected)
+	}
+
+	// The same as the value's JSON encoding
+	var fromJSON map[string]interface{}
+	encoded, _ := Marshal(value, transform.ConventionalKeys())
+	json.Unmarshal(encoded, &fromJSON)
+
+	if !reflect.DeepEqual(fromJSON, converted) {
+		t.Errorf("converted was `%#v`, when expected to be `%#v`", converted, fromJSON)
+	}
+
+	var decoded model
+	if err := FromMap(converted, &decoded, transform.ConventionalKeys()); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if !reflect.DeepEqual(value, decoded) {
+		t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, value)
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	source := map[string]interface{}{
+		"image_url":  "a",
+		"count":      float64(2),
+		"owner":      map[string]interface{}{"email_address": "b@example.com"},
+		"tags":       []interface{}{"c"},
+		"by_key":     map[string]interface{}{"some_key": json.Number("3")},
+		"created_at": "2015-11-17T20:43:31Z",
+		"unknown":    true,
+	}
+
+	var decoded mapsModel
+	err := FromMap(source, &decoded, transform.ConventionalKeys())
+
+	if _, isTypeErr := err.(*json.UnmarshalTypeError); !isTypeErr {
+		t.Errorf("Error (%T) %q isn't a *json.UnmarshalTypeError", err, err)
+	}
+
+	source["by_key"] = map[string]interface{}{"some_key": 3}
+
+	if err := FromMap(source, &decoded, transform.ConventionalKeys()); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expected := mapsModel{
+		ImageURL:  "a",
+		Count:     2,
+		Owner:     &mapsOwnerModel{"b@example.com"},
+		Tags:      []string{"c"},
+		ByKey:     map[string]int{"someKey": 3},
+		CreatedAt: time.Date(2015, 11, 17, 20, 43, 31, 0, time.UTC),
+	}
+
+	if !reflect.DeepEqual(expected, decoded) {
+		t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, expected)
+	}
+
+	if err := FromMap(map[string]interface{}{"count": 2.5}, &decoded); nil == err {
+		t.Error("FromMap was expected to fail with a lossy number conversion")
+	}
+
+	if err := FromMap(source, decoded); nil == err {
+		t.Error("FromMap was expected to fail with a non-pointer value")
+	}
+}
+
+func TestCodec_ToMap(t *testing.T) {
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+		DisallowUnknownFields(),
+	)
+
+	value := domainModel{OwnerName: "a", Vendor: vendorModel{"b", "B"}}
+
+	converted, err := codec.ToMap(value)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expected := map[string]interface{}{
+		"owner_name": "a",
+		"vendor":     map[string]interface{}{"accountID": "b", "displayName": "B"},
+		"vendors":    []*vendorModel(nil),
+		"by_key":     nil,
+		"dynamic":    nil,
+	}
+
+	if !reflect.DeepEqual(expected, converted) {
+		t.Errorf("converted was `%#v`, when expected to be `%#v`", converted, expected)
+	}
+
+	var decoded domainModel
+	if err := codec.FromMap(converted, &decoded); nil != err || !reflect.DeepEqual(value, decoded) {
+		t.Errorf("decoded was `%+v` (%v), when expected to be `%+v`", decoded, err, value)
+	}
+
+	converted["unknown"] = 1
+
+	if err, isUnknownErr := codec.FromMap(converted, &decoded).(*UnknownFieldsError); !isUnknownErr || !reflect.DeepEqual([]string{"unknown"}, err.Paths) {
+		t.Errorf("Error (%T) %v isn't the expected *UnknownFieldsError", err, err)
+	}
+}
+
+func TestToMap_AssertExtrasAreKept(t *testing.T) {
+	value := extrasItemModel{"a", Extras{"other_Key": json.RawMessage(`1`), "image_url": json.RawMessage(`"b"`)}}
+
+	converted, err := ToMap(value, transform.ConventionalKeys())
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expected := map[string]interface{}{"image_url": "a", "other_Key": json.RawMessage(`1`)}
+
+	if !reflect.DeepEqual(expected, converted) {
+		t.Errorf("converted was `%#v`, when expected to be `%#v`", converted, expected)
+	}
+
+	var decoded extrasItemModel
+	if err := FromMap(converted, &decoded, transform.ConventionalKeys()); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expected := (Extras{"other_Key": json.RawMessage(`1`)}); "a" != decoded.ImageURL || !reflect.DeepEqual(expected, decoded.Extra) {
+		t.Errorf("decoded was `%+v`, when expected to have the extras `%v`", decoded, expected)
+	}
+}
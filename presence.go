@@ -0,0 +1,64 @@
+package conjson
+
+import (
+	"sort"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+// Presence is a set of the fields of decoded structs, by their Go paths, that
+// records whether each field was present in the decoded JSON data, once the
+// data's keys were transformed.
+//
+// A Go path is the Go names of the fields leading to a field, along with the
+// indexes of slice and array elements and the transformed keys of map entries,
+// joined with dots, such as "Owner.Email" or "Items.1.ImageURL".
+//
+// Every field of each struct that a JSON object is decoded into is recorded as
+// either present (true) or absent (false). The fields of structs that aren't
+// decoded into, such as those within an absent or null field, or those of
+// types that decode themselves, aren't recorded.
+type Presence map[string]bool
+
+// UnmarshalPresence takes JSON encoded data, a pointer value, and a variable
+// number of `transform.Transformer`s and stores the result of decoding the
+// data in the pointed to value, with the given transformers having run on the
+// input, and returns the presence of the fields of the decoded structs in the
+// data.
+//
+// The presence of a field tells a field that was set to its zero value apart
+// from a field that wasn't sent at all, such as for partial updates.
+func UnmarshalPresence(data []byte, value interface{}, transformers ...transform.Transformer) (Presence, error) {
+	return newCodec(ignoreContext(transformers)).UnmarshalPresence(data, value)
+}
+
+// Has takes a Go path and returns whether the field at the path was present.
+func (p Presence) Has(path string) bool {
+	return p[path]
+}
+
+// Present returns the sorted Go paths of the fields that were present.
+func (p Presence) Present() []string {
+	return p.paths(true)
+}
+
+// Absent returns the sorted Go paths of the fields that were absent.
+func (p Presence) Absent() []string {
+	return p.paths(false)
+}
+
+// paths takes a presence and returns the sorted Go paths of the fields with
+// the given presence.
+func (p Presence) paths(present bool) []string {
+	var paths []string
+
+	for path, isPresent := range p {
+		if present == isPresent {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
@@ -0,0 +1,118 @@
+package conjson
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+type presenceOwnerModel struct {
+	Email       string
+	DisplayName string
+}
+
+type presenceModel struct {
+	ImageURL string
+	IsActive bool
+	Owner    *presenceOwnerModel
+	Items    []codecModel
+}
+
+func TestUnmarshalPresence(t *testing.T) {
+	const inputJSON = `{"image_url":"","owner":{"email":"a@example.com"},"items":[{"is_active":false},{}]}`
+
+	var decoded presenceModel
+	presence, err := UnmarshalPresence([]byte(inputJSON), &decoded, transform.ConventionalKeys())
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if "a@example.com" != decoded.Owner.Email || 2 != len(decoded.Items) {
+		t.Errorf("decoded was `%+v`, when expected to be decoded", decoded)
+	}
+
+	expectedPresent := []string{"ImageURL", "Items", "Items.0.IsActive", "Owner", "Owner.Email"}
+	expectedAbsent := []string{"IsActive", "Items.0.ImageURL", "Items.1.ImageURL", "Items.1.IsActive", "Owner.DisplayName"}
+
+	if present := presence.Present(); !reflect.DeepEqual(expectedPresent, present) {
+		t.Errorf("Present was `%v`, when expected to be `%v`", present, expectedPresent)
+	}
+
+	if absent := presence.Absent(); !reflect.DeepEqual(expectedAbsent, absent) {
+		t.Errorf("Absent was `%v`, when expected to be `%v`", absent, expectedAbsent)
+	}
+
+	if !presence.Has("ImageURL") || presence.Has("IsActive") || presence.Has("Unknown") {
+		t.Errorf("Has didn't match the presence `%v`", presence)
+	}
+}
+
+func TestUnmarshalPresence_AssertNullFieldsArePresent(t *testing.T) {
+	decoded := presenceModel{Owner: &presenceOwnerModel{}}
+	presence, err := UnmarshalPresence([]byte(`{"Owner":null}`), &decoded)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if !presence.Has("Owner") || nil != decoded.Owner {
+		t.Errorf("presence was `%v`, when expected to have a present Owner", presence)
+	}
+
+	if _, isRecorded := presence["Owner.Email"]; isRecorded {
+		t.Errorf("presence was `%v`, when expected to not record the fields of a null struct", presence)
+	}
+}
+
+func TestCodec_UnmarshalPresence(t *testing.T) {
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+	)
+
+	var decoded domainModel
+	presence, err := codec.UnmarshalPresence([]byte(`{"vendor":{"accountID":"a"},"by_key":{"some_key":{}}}`), &decoded)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expected := []string{"ByKey", "Vendor", "Vendor.AccountID"}
+
+	if present := presence.Present(); !reflect.DeepEqual(expected, present) {
+		t.Errorf("Present was `%v`, when expected to be `%v`", present, expected)
+	}
+
+	if !reflect.DeepEqual([]string{"ByKey.someKey.AccountID", "ByKey.someKey.DisplayName"}, presence.Absent()[:2]) {
+		t.Errorf("Absent was `%v`, when expected to contain the fields of map values", presence.Absent())
+	}
+
+	if presence, err := codec.UnmarshalPresence([]byte(`{"vendor":`), &decoded); nil == err || nil != presence {
+		t.Errorf("UnmarshalPresence returned `%v` (%v), when expected to return an error", presence, err)
+	}
+}
+
+func TestUnmarshalPresence_AssertTransformedStructureIsTracked(t *testing.T) {
+	const inputJSON = `{"data":{"image_url":"","owner.email":"a@example.com"}}`
+
+	var decoded presenceModel
+	presence, err := UnmarshalPresence(
+		[]byte(inputJSON),
+		&decoded,
+		transform.Envelope("data"),
+		transform.Flatten("."),
+		transform.ConventionalKeys(),
+	)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expectedPresent := []string{"ImageURL", "Owner", "Owner.Email"}
+
+	if present := presence.Present(); !reflect.DeepEqual(expectedPresent, present) {
+		t.Errorf("Present was `%v`, when expected to be `%v`", present, expectedPresent)
+	}
+}
@@ -0,0 +1,80 @@
+package conjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+// KeySpellings is a set of the original JSON object keys of decoded struct
+// fields and map entries, by their Go paths, so that the same keys may be
+// reproduced exactly when the decoded value is encoded again.
+//
+// Go paths are the same as those of a Presence, such as "Owner.Email" or
+// "Items.1.ImageURL".
+type KeySpellings map[string]string
+
+// UnmarshalKeySpellings takes JSON encoded data, a pointer value, and a
+// variable number of `transform.Transformer`s and stores the result of
+// decoding the data in the pointed to value, with the given transformers
+// having run on the input, and returns the original spelling of the keys of
+// the decoded fields and map entries.
+//
+// Passing the returned spellings to MarshalKeySpellings reproduces the
+// original keys, regardless of the key convention of the transformers. The
+// keys within any part of the data whose structure was changed by the
+// transformers, such as by transform.Envelope, aren't recorded.
+func UnmarshalKeySpellings(data []byte, value interface{}, transformers ...transform.Transformer) (KeySpellings, error) {
+	return newCodec(ignoreContext(transformers)).UnmarshalKeySpellings(data, value)
+}
+
+// MarshalKeySpellings takes a value, key spellings, and a variable number of
+// `transform.Transformer`s and returns the JSON encoding of the value, with
+// the given transformers having run on the output, and with the keys of the
+// fields and map entries that have a recorded spelling respelled as recorded.
+//
+// Fields and map entries without a recorded spelling, such as those that were
+// absent from the decoded data, keep the keys produced by the transformers.
+func MarshalKeySpellings(value interface{}, spellings KeySpellings, transformers ...transform.Transformer) ([]byte, error) {
+	return newCodec(ignoreContext(transformers)).MarshalKeySpellings(value, spellings)
+}
+
+// UnmarshalKeySpellings takes JSON encoded data and a pointer value and
+// behaves like Unmarshal, but also returns the original spelling of the keys
+// of the decoded fields and map entries.
+//
+// See the documentation for the package-level UnmarshalKeySpellings for more
+// details.
+func (c *Codec) UnmarshalKeySpellings(data []byte, value interface{}) (KeySpellings, error) {
+	spellings := KeySpellings{}
+
+	if err := json.Unmarshal(data, &unmarshaler{context.Background(), value, c, tracker{spellings: spellings}}); nil != err {
+		return nil, err
+	}
+
+	return spellings, nil
+}
+
+// MarshalKeySpellings takes a value and key spellings and behaves like
+// Marshal, but respells the keys of the fields and map entries that have a
+// recorded spelling as recorded.
+//
+// See the documentation for the package-level MarshalKeySpellings for more
+// details.
+func (c *Codec) MarshalKeySpellings(value interface{}, spellings KeySpellings) ([]byte, error) {
+	return marshalJSON(&marshaler{context.Background(), value, c, spellings})
+}
+
+// quote takes a string and returns it as a JSON string, without escaping HTML
+// characters, so that the string is reproduced as closely as possible.
+func quote(s string) []byte {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n"))
+}
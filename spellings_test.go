@@ -0,0 +1,131 @@
+package conjson
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+type spellingsModel struct {
+	ImageURL      string
+	ReferredByURL string
+	IsActive      bool
+	Items         []codecModel
+	ByKey         map[string]int
+}
+
+func TestKeySpellings(t *testing.T) {
+	const inputJSON = `{"imageURL":"a","referred_by_url":"b","Items":[{"image_Url":"c"},{"ImageURL":"d"}],"by_key":{"some_key":1}}`
+
+	var decoded spellingsModel
+	spellings, err := UnmarshalKeySpellings([]byte(inputJSON), &decoded, transform.ConventionalKeys())
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	expectedSpellings := KeySpellings{
+		"ImageURL":         "imageURL",
+		"ReferredByURL":    "referred_by_url",
+		"Items":            "Items",
+		"Items.0.ImageURL": "image_Url",
+		"Items.1.ImageURL": "ImageURL",
+		"ByKey":            "by_key",
+		"ByKey.someKey":    "some_key",
+	}
+
+	if !reflect.DeepEqual(expectedSpellings, spellings) {
+		t.Errorf("spellings were `%v`, when expected to be `%v`", spellings, expectedSpellings)
+	}
+
+	decoded.Items[0].ImageURL = "changed"
+
+	output, err := MarshalKeySpellings(decoded, spellings, transform.ConventionalKeys())
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	// Fields that weren't sent keep the convention of the transformers
+	const expectedJSON = `{"imageURL":"a","referred_by_url":"b","is_active":false,` +
+		`"Items":[{"image_Url":"changed","is_active":false},{"ImageURL":"d","is_active":false}],` +
+		`"by_key":{"some_key":1}}`
+
+	if expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	// Without spellings, the keys are normalized
+	expected, _ := Marshal(decoded, transform.ConventionalKeys())
+
+	if output, _ := MarshalKeySpellings(decoded, nil, transform.ConventionalKeys()); string(expected) != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expected)
+	}
+}
+
+func TestCodec_KeySpellings(t *testing.T) {
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+	)
+
+	const inputJSON = `{"OwnerName":"a","vendor":{"AccountID":"b","display_name":"c"}}`
+
+	var decoded domainModel
+	spellings, err := codec.UnmarshalKeySpellings([]byte(inputJSON), &decoded)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	decoded.Vendors = []*vendorModel{{"d", "D"}}
+
+	output, err := codec.MarshalKeySpellings(decoded, spellings)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	const expectedJSON = `{"OwnerName":"a",` +
+		`"vendor":{"AccountID":"b","display_name":"c"},` +
+		`"vendors":[{"accountID":"d","displayName":"D"}],` +
+		`"by_key":null,"dynamic":null}`
+
+	if expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+}
+
+func TestQuote_AssertHTMLIsntEscaped(t *testing.T) {
+	if quoted := quote(`<a&b>"`); `"<a&b>\""` != string(quoted) {
+		t.Errorf("quoted was %s, when expected to be %s", quoted, `"<a&b>\""`)
+	}
+}
+
+func TestKeySpellings_AssertReorderedKeysAreRespelled(t *testing.T) {
+	type model struct {
+		Zed   string
+		Alpha string
+		Items []map[string]int
+	}
+
+	var decoded model
+	spellings, err := UnmarshalKeySpellings([]byte(`{"ZED":"z","ALPHA":"a","ITEMS":[{"b_KEY":1,"a_key":2}]}`), &decoded)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	output, err := MarshalKeySpellings(decoded, spellings, transform.ConventionalKeys(), transform.Canonical())
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	const expectedJSON = `{"ALPHA":"a","ITEMS":[{"a_key":2,"b_KEY":1}],"ZED":"z"}`
+
+	if expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+}
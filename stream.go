@@ -0,0 +1,150 @@
+package conjson
+
+import (
+	"context"
+	"io"
+	"iter"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+// StreamEncoder is an interface defining a JSON encoder that, in addition to
+// encoding single values, is able to incrementally encode a JSON array from
+// values as they're produced.
+type StreamEncoder interface {
+	ContextEncoder
+
+	// EncodeSeq writes a JSON array to the underlying writer, encoding and
+	// writing each value of the given sequence as an element of the array as
+	// soon as it's produced. Any context-aware transformers are run within the
+	// given context.
+	//
+	// If the given context is done before the sequence is exhausted, the
+	// array is closed, so that the written JSON remains well-formed, and the
+	// context's error is returned.
+	EncodeSeq(context.Context, iter.Seq[interface{}]) error
+
+	// EncodeChan writes a JSON array to the underlying writer, encoding and
+	// writing each value received from the given channel as an element of the
+	// array as soon as it's received, until the channel is closed.
+	//
+	// If the given context is done before the channel is closed, the array is
+	// closed, so that the written JSON remains well-formed, and the context's
+	// error is returned.
+	EncodeChan(context.Context, <-chan interface{}) error
+}
+
+// streamEncoder is a structure that wraps an `io.Writer` and a list of
+// transformers to enable JSON encoding, including the incremental encoding of
+// JSON arrays, with output transformations.
+type streamEncoder struct {
+	writer io.Writer
+	codec  *Codec
+}
+
+// NewStreamEncoder takes an `io.Writer` and a variable number of
+// `transform.Transformer`s and returns a `StreamEncoder` that runs the given
+// transformers upon JSON encoding.
+//
+// Unlike the encoder returned by NewEncoder, the returned encoder writes
+// directly to the given writer, so that it may write the JSON array delimiters
+// around incrementally encoded values, and so that each value is written
+// exactly as the transformers produced it, like the output of Marshal, rather
+// than compacted and HTML-escaped by `encoding/json`. Like
+// `encoding/json.Encoder`, each encoded top-level value is followed by a
+// newline character.
+func NewStreamEncoder(writer io.Writer, transformers ...transform.Transformer) StreamEncoder {
+	return NewContextStreamEncoder(writer, ignoreContext(transformers)...)
+}
+
+// NewContextStreamEncoder takes an `io.Writer` and a variable number of
+// `transform.ContextTransformer`s and returns a `StreamEncoder` that runs the
+// given transformers upon JSON encoding, within the context passed to the
+// encoding method.
+//
+// See the documentation for NewStreamEncoder for more details.
+func NewContextStreamEncoder(writer io.Writer, transformers ...transform.ContextTransformer) StreamEncoder {
+	return &streamEncoder{writer, newCodec(transformers)}
+}
+
+func (e *streamEncoder) Encode(value interface{}) error {
+	return e.EncodeContext(context.Background(), value)
+}
+
+func (e *streamEncoder) EncodeContext(ctx context.Context, value interface{}) error {
+	if err := ctx.Err(); nil != err {
+		return err
+	}
+
+	encoded, err := marshalJSON(&marshaler{ctx, value, e.codec, nil})
+
+	if nil != err {
+		return err
+	}
+
+	_, err = e.writer.Write(append(encoded, '\n'))
+
+	return err
+}
+
+func (e *streamEncoder) EncodeSeq(ctx context.Context, values iter.Seq[interface{}]) error {
+	if err := ctx.Err(); nil != err {
+		return err
+	}
+
+	if _, err := io.WriteString(e.writer, "["); nil != err {
+		return err
+	}
+
+	var err error
+	index := 0
+
+	for value := range values {
+		if err = ctx.Err(); nil != err {
+			break
+		}
+
+		var encoded []byte
+
+		if encoded, err = marshalJSON(&marshaler{ctx, value, e.codec, nil}); nil != err {
+			break
+		}
+
+		if index > 0 {
+			encoded = append([]byte(","), encoded...)
+		}
+
+		if _, err = e.writer.Write(encoded); nil != err {
+			break
+		}
+
+		index++
+	}
+
+	// The sequence may have ended early due to the context being done
+	if nil == err {
+		err = ctx.Err()
+	}
+
+	// Always close the array, so that the written JSON remains well-formed
+	if _, closeErr := io.WriteString(e.writer, "]\n"); nil == err {
+		err = closeErr
+	}
+
+	return err
+}
+
+func (e *streamEncoder) EncodeChan(ctx context.Context, values <-chan interface{}) error {
+	return e.EncodeSeq(ctx, func(yield func(interface{}) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case value, isOpen := <-values:
+				if !isOpen || !yield(value) {
+					return
+				}
+			}
+		}
+	})
+}
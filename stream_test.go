@@ -0,0 +1,217 @@
+package conjson
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+var (
+	// Compile time interface assertion
+	_ StreamEncoder = (*streamEncoder)(nil)
+)
+
+type streamModel struct {
+	ImageURL string
+}
+
+// cancelingWriter is a buffer that cancels a context after a given number of
+// writes.
+type cancelingWriter struct {
+	written     bytes.Buffer
+	cancelAfter int
+	cancel      context.CancelFunc
+}
+
+func (w *cancelingWriter) Write(data []byte) (int, error) {
+	if w.cancelAfter--; 0 == w.cancelAfter {
+		defer w.cancel()
+	}
+
+	return w.written.Write(data)
+}
+
+func TestNewStreamEncoder(t *testing.T) {
+	if _, isStreamEncoder := NewStreamEncoder(ioutil.Discard).(StreamEncoder); !isStreamEncoder {
+		t.Error("NewStreamEncoder didn't return a type compatible with StreamEncoder")
+	}
+
+	if _, isStreamEncoder := NewStreamEncoder(ioutil.Discard, noOpTransformer).(StreamEncoder); !isStreamEncoder {
+		t.Error("NewStreamEncoder didn't return a type compatible with StreamEncoder")
+	}
+
+	var buf bytes.Buffer
+	if err := NewStreamEncoder(&buf, transform.ConventionalKeys()).Encode(streamModel{"a"}); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "{\"image_url\":\"a\"}\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+	}
+}
+
+func TestNewStreamEncoder_AssertOutputIsntReformatted(t *testing.T) {
+	var buf bytes.Buffer
+
+	encoder := NewStreamEncoder(&buf, transform.ConventionalKeys(), transform.Canonical(), transform.Indent("", "  "))
+
+	if err := encoder.Encode(map[string]string{"htmlBody": "<b>"}); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "{\n  \"html_body\": \"<b>\"\n}\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+	}
+}
+
+func TestNewContextStreamEncoder(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "context value")
+	directionRan := transform.Unmarshal
+
+	var buf bytes.Buffer
+	if err := NewContextStreamEncoder(&buf, contextTransformer(&directionRan)).EncodeSeq(ctx, func(yield func(interface{}) bool) {
+		_ = yield(1) && yield(2)
+	}); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "[\"context value\",\"context value\"]\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+
+		if transform.Marshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Marshal")
+		}
+	}
+}
+
+func TestStreamEncoder_EncodeSeq(t *testing.T) {
+	values := func(count int) func(func(interface{}) bool) {
+		return func(yield func(interface{}) bool) {
+			for i := 0; i < count; i++ {
+				if !yield(streamModel{string(rune('a' + i))}) {
+					return
+				}
+			}
+		}
+	}
+
+	for _, testCase := range []struct {
+		count          int
+		expectedOutput string
+	}{
+		{0, "[]\n"},
+		{1, "[{\"image_url\":\"a\"}]\n"},
+		{3, "[{\"image_url\":\"a\"},{\"image_url\":\"b\"},{\"image_url\":\"c\"}]\n"},
+	} {
+		var buf bytes.Buffer
+		timesRan, directionRan := 0, transform.Unmarshal
+
+		encoder := NewStreamEncoder(&buf, transform.ConventionalKeys(), mockTransformer(&timesRan, &directionRan))
+
+		if err := encoder.EncodeSeq(context.Background(), values(testCase.count)); nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if testCase.expectedOutput != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), testCase.expectedOutput)
+		}
+
+		if testCase.count != timesRan {
+			t.Errorf("timesRan was `%d`, when expected to be `%d`", timesRan, testCase.count)
+		}
+
+		if 0 < testCase.count && transform.Marshal != directionRan {
+			t.Error("directionRan isn't the expected transform.Marshal")
+		}
+	}
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := NewStreamEncoder(&buf).EncodeSeq(ctx, func(yield func(interface{}) bool) {
+		for i := 1; i <= 4; i++ {
+			if 3 == i {
+				cancel()
+			}
+
+			if !yield(i) {
+				return
+			}
+		}
+	}); true {
+		if context.Canceled != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, context.Canceled)
+		}
+
+		if expected := "[1,2]\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+	}
+
+	buf = bytes.Buffer{}
+	if err := NewStreamEncoder(&buf).EncodeSeq(ctx, values(1)); true {
+		if context.Canceled != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, context.Canceled)
+		}
+
+		if 0 != buf.Len() {
+			t.Errorf("Output %q was written for an already done context", buf.String())
+		}
+	}
+
+	shouldError := errorMarshaler(true)
+	buf = bytes.Buffer{}
+	if err := NewStreamEncoder(&buf).EncodeSeq(context.Background(), func(yield func(interface{}) bool) {
+		_ = yield(1) && yield(&shouldError) && yield(2)
+	}); true {
+		if nil == err {
+			t.Error("Expected error was nil")
+		}
+
+		if expected := "[1]\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+	}
+}
+
+func TestStreamEncoder_EncodeChan(t *testing.T) {
+	var buf bytes.Buffer
+	values := make(chan interface{}, 3)
+	values <- streamModel{"a"}
+	values <- streamModel{"b"}
+	values <- streamModel{"c"}
+	close(values)
+
+	if err := NewStreamEncoder(&buf, transform.ConventionalKeys()).EncodeChan(context.Background(), values); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if expected := "[{\"image_url\":\"a\"},{\"image_url\":\"b\"},{\"image_url\":\"c\"}]\n"; expected != buf.String() {
+			t.Errorf("Output %q doesn't match expected %q", buf.String(), expected)
+		}
+	}
+
+	// A channel that's never closed should stop being read once cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	writer := &cancelingWriter{cancelAfter: 2, cancel: cancel}
+	values = make(chan interface{}, 1)
+	values <- 1
+	if err := NewStreamEncoder(writer).EncodeChan(ctx, values); true {
+		if context.Canceled != err {
+			t.Errorf("err was `%v`, when expected to be `%v`", err, context.Canceled)
+		}
+
+		if expected := "[1]\n"; expected != writer.written.String() {
+			t.Errorf("Output %q doesn't match expected %q", writer.written.String(), expected)
+		}
+	}
+}
@@ -0,0 +1,839 @@
+package conjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+	"github.com/Rican7/conjson/transform"
+)
+
+// tracker is a structure that holds what's being tracked about the fields of
+// walked values, if anything.
+type tracker struct {
+	presence  Presence
+	spellings KeySpellings
+}
+
+// isTracking returns whether anything is being tracked.
+func (t tracker) isTracking() bool {
+	return nil != t.presence || nil != t.spellings
+}
+
+// isolated is an interface defining a value that runs its own transformations
+// of its JSON, and whose keys must therefore be left untouched by the
+// transformers of any value that it's nested within.
+type isolated interface {
+	isolatedJSON()
+}
+
+var (
+	isolatedType           = reflect.TypeOf((*isolated)(nil)).Elem()
+	conventionProviderType = reflect.TypeOf((*ConventionProvider)(nil)).Elem()
+)
+
+// subtree is a structure defining a region of a JSON document and the
+// transformers that are run on that region, once each of its nested subtrees
+// is transformed with its own transformers.
+type subtree struct {
+	node         *jsontree.Node
+	start, end   int
+	transformers []transform.ContextTransformer
+	children     []*subtree
+
+	// extras is whether the subtree is the member of an Extras field, whose
+	// members are emitted in place of the field's member when marshaling
+	extras bool
+
+	// opaque is whether the subtree is left untouched by any transformers
+	opaque bool
+
+	// convention is the type whose own convention the subtree's transformers
+	// are, or nil if they're the codec's transformers
+	convention reflect.Type
+}
+
+// walker is a structure that holds the state of a walk of a JSON document
+// alongside the Go value or type that the document represents.
+type walker struct {
+	ctx       context.Context
+	codec     *Codec
+	data      []byte
+	direction transform.Direction
+
+	// placeholder is a prefix, unique within the document, of the
+	// placeholders that stand in for opaque subtrees during transformation
+	placeholder []byte
+
+	// path is the path, in the original keys and array indexes, of the node
+	// currently being walked
+	path []string
+
+	// goPath is the path, in the Go names of struct fields, the transformed
+	// map keys, and array indexes, of the node currently being walked
+	goPath []string
+
+	// tracker records what's tracked about the fields of the walked values
+	tracker
+
+	// respellings are the keys to replace the keys of the walked document's
+	// members with, by the offsets of the members' keys
+	respellings map[int]string
+
+	// unknownFields is the list of paths of the object keys that don't map to
+	// any field of the struct that they're decoded into
+	unknownFields []string
+}
+
+// newSubtree takes a node and a list of transformers and returns a new
+// subtree spanning the node's region of the document.
+func newSubtree(node *jsontree.Node, transformers []transform.ContextTransformer) *subtree {
+	return &subtree{node: node, start: node.Start, end: node.End, transformers: transformers}
+}
+
+// root returns the subtree spanning the entire document being walked, with
+// the codec's transformers.
+func (w *walker) root() *subtree {
+	return &subtree{end: len(w.data), transformers: w.codec.transformers}
+}
+
+// parse takes the root subtree and parses the document being walked into the
+// root's node, returning whether the document could be parsed.
+func (w *walker) parse(root *subtree) bool {
+	node, err := jsontree.Parse(w.data)
+
+	root.node = node
+
+	return nil == err
+}
+
+// walkValue takes a Go value, the node of the value's JSON encoding, and the
+// subtree that the node is within, and adds a subtree for each nested value
+// that has its own transformations.
+func (w *walker) walkValue(v reflect.Value, node *jsontree.Node, parent *subtree) {
+	for v.IsValid() {
+		if parent = w.enter(v.Type(), node, parent); nil == parent {
+			return
+		}
+
+		if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface {
+			break
+		}
+
+		if v.IsNil() {
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	// Values that encode themselves have no predictable structure
+	if !v.IsValid() || implementsEither(v.Type(), marshalerType, textMarshalerType) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if jsontree.Object == node.Kind {
+			fields := typeFields(v.Type())
+
+			for _, member := range node.Members {
+				if f, found := lookupField(fields, member.Key); found {
+					if extrasType == f.typ {
+						parent.children = append(parent.children, w.extrasSubtree(member))
+
+						continue
+					}
+
+					w.goPath = append(w.goPath, f.goName)
+					w.respell(member)
+					w.walkValue(fieldByIndex(v, f.index), member.Value, parent)
+					w.goPath = w.goPath[:len(w.goPath)-1]
+				}
+			}
+		}
+	case reflect.Map:
+		if jsontree.Object == node.Kind {
+			values := make(map[string]reflect.Value, v.Len())
+
+			for iter := v.MapRange(); iter.Next(); {
+				if key, isValid := mapKeyString(iter.Key()); isValid {
+					values[key] = iter.Value()
+				}
+			}
+
+			for _, member := range node.Members {
+				w.goPath = append(w.goPath, member.Key)
+				w.respell(member)
+				w.walkValue(values[member.Key], member.Value, parent)
+				w.goPath = w.goPath[:len(w.goPath)-1]
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if jsontree.Array == node.Kind {
+			for i, element := range node.Elements {
+				if i < v.Len() {
+					w.goPath = append(w.goPath, strconv.Itoa(i))
+					w.walkValue(v.Index(i), element, parent)
+					w.goPath = w.goPath[:len(w.goPath)-1]
+				}
+			}
+		}
+	}
+}
+
+// decode takes a Go type, a value of the transformed document to be decoded
+// into the type, the trace of the transformed document, and the subtree whose
+// transformers the value was transformed with, and returns the value as it's
+// to be decoded, or nil if it's to be decoded as it is, having recorded what's
+// tracked about the value's fields and the value's unknown fields.
+//
+// Values of types with their own convention get back their original keys,
+// and are then transformed with the convention's transformers, so that the
+// transformers of the enclosing values don't decide their keys, though they
+// still see them. Opaque values get back their original JSON entirely.
+func (w *walker) decode(t reflect.Type, node *jsontree.Node, tr *trace, within *subtree) []byte {
+	var converted *trace
+
+	for {
+		if implements(t, isolatedType) {
+			return tr.restore(node).data
+		}
+
+		transformers, hasOwn := w.codec.typeTransformers(t)
+
+		if !hasOwn && w.isOpaque(t) {
+			if origin := tr.origin(node); nil != origin {
+				return origin.Raw(w.data)
+			}
+
+			return nil
+		}
+
+		if hasOwn {
+			within = w.within(t, within)
+
+			if converted = w.run(tr.restore(node), transformers, nil); nil == converted.root {
+				return converted.data
+			}
+
+			tr, node = converted, converted.root
+		}
+
+		if t.Kind() != reflect.Pointer {
+			break
+		}
+
+		t = t.Elem()
+	}
+
+	var decoded []byte
+
+	// Values that decode themselves have no predictable structure
+	if !implementsEither(t, unmarshalerType, textUnmarshalerType) {
+		switch t.Kind() {
+		case reflect.Struct:
+			if jsontree.Object == node.Kind {
+				decoded = w.decodeStruct(t, node, tr, within)
+			}
+		case reflect.Map:
+			if jsontree.Object == node.Kind {
+				values := make([][]byte, len(node.Members))
+
+				for i := range node.Members {
+					member, original := &node.Members[i], tr.memberOrigin(&node.Members[i])
+
+					w.path = append(w.path, originalKey(member, original))
+					w.goPath = append(w.goPath, member.Key)
+					w.recordSpelling(original)
+					values[i] = w.decode(t.Elem(), member.Value, tr, within)
+					w.path, w.goPath = w.path[:len(w.path)-1], w.goPath[:len(w.goPath)-1]
+				}
+
+				decoded = spliceDecoded(tr.data, node, childNodes(node), values)
+			}
+		case reflect.Slice, reflect.Array:
+			if jsontree.Array == node.Kind {
+				values := make([][]byte, len(node.Elements))
+
+				for i, element := range node.Elements {
+					w.path = append(w.path, strconv.Itoa(i))
+					w.goPath = append(w.goPath, strconv.Itoa(i))
+					values[i] = w.decode(t.Elem(), element, tr, within)
+					w.path, w.goPath = w.path[:len(w.path)-1], w.goPath[:len(w.goPath)-1]
+				}
+
+				decoded = spliceDecoded(tr.data, node, node.Elements, values)
+			}
+		}
+	}
+
+	if nil == decoded && nil != converted {
+		decoded = converted.data
+	}
+
+	return decoded
+}
+
+// decodeStruct takes a struct type, a JSON object of the transformed document
+// to be decoded into the struct, the trace of the transformed document, and
+// the subtree whose transformers the object was transformed with, and returns
+// the object as it's to be decoded, or nil if it's to be decoded as it is.
+//
+// The members that don't map to any field are moved into the member of the
+// struct's Extras field, with their original keys and values, if the struct
+// has one, and are otherwise recorded as unknown fields.
+func (w *walker) decodeStruct(t reflect.Type, node *jsontree.Node, tr *trace, within *subtree) []byte {
+	fields := typeFields(t)
+	extras, _ := extrasField(fields)
+	r := &rewrite{name: extras.name, captured: make(map[int][]byte), dropped: make(map[int]bool)}
+	values := make([][]byte, len(node.Members))
+
+	for i := range node.Members {
+		member, original := &node.Members[i], tr.memberOrigin(&node.Members[i])
+
+		w.path = append(w.path, originalKey(member, original))
+
+		if f, found := w.lookupMember(fields, member, original, within); found && extrasType != f.typ {
+			w.goPath = append(w.goPath, f.goName)
+			w.recordPresence(true)
+			w.recordSpelling(original)
+			values[i] = w.decode(f.typ, member.Value, tr, within)
+			w.goPath = w.goPath[:len(w.goPath)-1]
+		} else if extrasType == extras.typ {
+			r.captured[i] = w.capture(member, original, tr)
+		} else {
+			w.unknownFields = append(w.unknownFields, strings.Join(w.path, "."))
+
+			// Keep `encoding/json` from matching the key case-insensitively
+			if w.codec.exactFieldNames {
+				r.dropped[i] = true
+			}
+		}
+
+		w.path = w.path[:len(w.path)-1]
+	}
+
+	for _, f := range fields {
+		if extrasType != f.typ {
+			w.goPath = append(w.goPath, f.goName)
+			w.recordPresence(false)
+			w.goPath = w.goPath[:len(w.goPath)-1]
+		}
+	}
+
+	isReplaced := fillDecoded(tr.data, childNodes(node), values)
+
+	if 0 < len(r.captured) || 0 < len(r.dropped) {
+		return rewriteObject(r, node, tr.data, values)
+	}
+
+	if !isReplaced {
+		return nil
+	}
+
+	return splice(tr.data, node, childNodes(node), values)
+}
+
+// spliceDecoded takes a document, a node of the document, a list of the node's
+// children, and the decoded forms of the children, which are nil for the
+// children decoded as they are, and returns the node's value with each child
+// replaced by its decoded form, or nil if no child was replaced.
+func spliceDecoded(data []byte, node *jsontree.Node, children []*jsontree.Node, decoded [][]byte) []byte {
+	if !fillDecoded(data, children, decoded) {
+		return nil
+	}
+
+	return splice(data, node, children, decoded)
+}
+
+// fillDecoded takes a document, a list of nodes of the document, and the
+// decoded forms of the nodes, which are nil for the nodes decoded as they are,
+// and fills in the decoded forms of those nodes with the nodes' values,
+// returning whether any node was replaced.
+func fillDecoded(data []byte, nodes []*jsontree.Node, decoded [][]byte) bool {
+	isReplaced := false
+
+	for i, node := range nodes {
+		if nil == decoded[i] {
+			decoded[i] = node.Raw(data)
+		} else {
+			isReplaced = true
+		}
+	}
+
+	return isReplaced
+}
+
+// originalKey takes a member of the transformed document and the member of the
+// original document that it was transformed from, if it's known, and returns
+// the member's original key, or its transformed key if it isn't known.
+func originalKey(member *jsontree.Member, original *jsontree.Member) string {
+	if nil == original {
+		return member.Key
+	}
+
+	return original.Key
+}
+
+// recordPresence takes whether the struct field currently being walked is
+// present and records it, if presence is being tracked. A field that's
+// already recorded as present stays present.
+func (w *walker) recordPresence(present bool) {
+	if nil == w.presence {
+		return
+	}
+
+	path := strings.Join(w.goPath, ".")
+	w.presence[path] = present || w.presence[path]
+}
+
+// recordSpelling takes the original JSON object member of the struct field or
+// map entry currently being walked, if it's known, and records the member's
+// key, if key spellings are being tracked.
+func (w *walker) recordSpelling(original *jsontree.Member) {
+	if nil != w.spellings && nil != original {
+		w.spellings[strings.Join(w.goPath, ".")] = original.Key
+	}
+}
+
+// respell takes a JSON object member of the document currently being walked,
+// and marks the member's key to be respelled with its recorded spelling, if
+// the struct field or map entry currently being walked has one.
+func (w *walker) respell(member jsontree.Member) {
+	if spelling, isRecorded := w.spellings[strings.Join(w.goPath, ".")]; isRecorded {
+		if nil == w.respellings {
+			w.respellings = make(map[int]string)
+		}
+
+		w.respellings[member.KeyStart] = spelling
+	}
+}
+
+// enter takes a Go type, the node of a value of that type, and the subtree
+// that the node is within, and returns the subtree that the node's nested
+// values are within, which is a new subtree if the type has its own
+// transformations. If the node's nested values must be left to the value's
+// own transformations, nil is returned.
+func (w *walker) enter(t reflect.Type, node *jsontree.Node, parent *subtree) *subtree {
+	transformers, hasOwn := w.codec.typeTransformers(t)
+	isIsolated := implements(t, isolatedType) || (!hasOwn && w.isOpaque(t))
+
+	if !hasOwn && !isIsolated {
+		return parent
+	}
+
+	// Isolated values without objects or arrays have no keys to keep
+	if isIsolated && !isStructured(node) {
+		return nil
+	}
+
+	entered := newSubtree(node, transformers)
+	parent.children = append(parent.children, entered)
+
+	if isIsolated {
+		entered.transformers = nil
+		entered.opaque = !implements(t, isolatedType)
+
+		return nil
+	}
+
+	return entered
+}
+
+// extrasSubtree takes the member of an Extras field and returns the subtree
+// of the member, spanning the entire member, and the comma that separates it
+// from another member when the member has no extra members to emit.
+func (w *walker) extrasSubtree(member jsontree.Member) *subtree {
+	extras := newSubtree(member.Value, nil)
+	extras.start, extras.extras = member.KeyStart, true
+
+	// The encoded document is compact, so any comma directly neighbors the
+	// member
+	if jsontree.Object != member.Value.Kind || 0 == len(member.Value.Members) {
+		if extras.end < len(w.data) && ',' == w.data[extras.end] {
+			extras.end++
+		} else if 0 < extras.start && ',' == w.data[extras.start-1] {
+			extras.start--
+		}
+	}
+
+	return extras
+}
+
+// isOpaque takes a type and returns whether values of the type must be left
+// untouched, as the codec treats values that encode or decode their own JSON
+// as opaque.
+func (w *walker) isOpaque(t reflect.Type) bool {
+	if !w.codec.opaqueMarshalers {
+		return false
+	}
+
+	if transform.Marshal == w.direction {
+		return implements(t, marshalerType)
+	}
+
+	return implements(t, unmarshalerType)
+}
+
+// within takes a type and the subtree that a value of the type is within and
+// returns the subtree that the value's nested values are within, which is a
+// subtree of the type's convention if the type has its own convention.
+func (w *walker) within(t reflect.Type, parent *subtree) *subtree {
+	transformers, hasOwn := w.codec.typeTransformers(t)
+
+	if !hasOwn {
+		return parent
+	}
+
+	return &subtree{transformers: transformers, convention: t}
+}
+
+// lookupField takes a list of fields, a JSON object key, and the subtree that
+// the key is within, and returns the field that the key's value is decoded
+// into.
+//
+// When the codec matches field names exactly, the key must be exactly the
+// field's wire name, rather than matching the field's name once transformed.
+func (w *walker) lookupField(fields []field, key string, within *subtree) (field, bool) {
+	if !w.codec.exactFieldNames {
+		return lookupField(fields, w.key(key, within))
+	}
+
+	for _, f := range fields {
+		if key == w.wireName(f.name, within) {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
+
+// lookupMember takes a list of fields, a member of the transformed document,
+// the member of the original document that it was transformed from, if it's
+// known, and the subtree whose transformers the member was transformed with,
+// and returns the field that the member's value is decoded into.
+//
+// When the codec matches field names exactly, the original key must be exactly
+// the field's wire name, or, when the original member isn't known, the
+// member's key must be exactly the field's wire name once transformed back.
+func (w *walker) lookupMember(fields []field, member, original *jsontree.Member, within *subtree) (field, bool) {
+	if !w.codec.exactFieldNames {
+		return lookupField(fields, member.Key)
+	}
+
+	for _, f := range fields {
+		if nil != original && original.Key == w.wireName(f.name, within) {
+			return f, true
+		}
+
+		if nil == original && member.Key == w.key(w.wireName(f.name, within), within) {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
+
+// key takes a JSON object key and the subtree that the key is within and
+// returns the key as it is once the subtree's transformers have run on it.
+func (w *walker) key(key string, within *subtree) string {
+	return w.probe(key, w.direction, within)
+}
+
+// wireName takes the name of a field and the subtree that the field is within
+// and returns the JSON object key that the field is marshaled as, once the
+// subtree's transformers have run on it.
+func (w *walker) wireName(name string, within *subtree) string {
+	return w.probe(name, transform.Marshal, within)
+}
+
+// probe is a structure defining a JSON object key probed against the
+// transformers of a convention in a direction.
+type probe struct {
+	convention reflect.Type
+	direction  transform.Direction
+	key        string
+}
+
+// probe takes a JSON object key, a direction, and the subtree that the key is
+// within, and returns the key as it is once the subtree's transformers have
+// run on it in the given direction.
+//
+// The transformers are probed with a document containing only the key, and
+// the key is followed through each of them, wherever they move it to, such as
+// into an envelope. Transformers that drop the key, such as transform.Redact,
+// or that lose track of it are skipped, as they don't decide its spelling.
+//
+// Probed keys are cached by the codec, so that the transformers only ever see
+// a probe document once for each key, convention, and direction.
+func (w *walker) probe(key string, direction transform.Direction, within *subtree) string {
+	probed := probe{within.convention, direction, key}
+
+	if transformed, isCached := w.codec.probes.Load(probed); isCached {
+		return transformed.(string)
+	}
+
+	transformed := key
+	quoted, _ := json.Marshal(key)
+	current := newTrace(append(append([]byte{'{'}, quoted...), ":true}"...))
+	member := &current.root.Members[0]
+
+	for _, transformer := range within.transformers {
+		next := w.step(current, transformer, direction)
+
+		if found := next.find(member); nil != found {
+			current, transformed = next, found.Key
+		}
+	}
+
+	cached, _ := w.codec.probes.LoadOrStore(probed, transformed)
+
+	return cached.(string)
+}
+
+// isStructured takes a node and returns whether the node is an object or an
+// array.
+func isStructured(node *jsontree.Node) bool {
+	return jsontree.Object == node.Kind || jsontree.Array == node.Kind
+}
+
+// placeholderFor takes the index of a nested subtree and returns a JSON string
+// that's unique within the document being walked, to stand in for the nested
+// subtree while the subtree it's within is being transformed.
+func (w *walker) placeholderFor(index int) []byte {
+	for nonce := 0; nil == w.placeholder; nonce++ {
+		placeholder := []byte(`"\u0000` + strconv.Itoa(nonce) + `\u0000`)
+
+		if !bytes.Contains(w.data, placeholder[1:]) {
+			w.placeholder = placeholder
+		}
+	}
+
+	return append(append(append([]byte(nil), w.placeholder...), strconv.Itoa(index)...), `\u0000"`...)
+}
+
+// transform takes the walker of the document and returns the result of
+// transforming the subtree's region of the document, when marshaling.
+//
+// Each nested subtree is transformed first, with its own transformers, and
+// the subtree's transformers then run on the entire region, so that they see
+// the nested subtrees' JSON, but the keys within the nested subtrees, and the
+// keys with a recorded spelling, are kept as they are. The JSON of opaque
+// subtrees is kept out of the transformers' sight entirely.
+func (s *subtree) transform(w *walker) []byte {
+	if 0 == len(s.children) && 0 == len(w.respellings) {
+		return transformBytes(w.ctx, w.data[s.start:s.end], w.direction, s.transformers)
+	}
+
+	var region []byte
+
+	// segments are the offsets of the region's untransformed parts, within
+	// the region and within the document, so that their keys may be related
+	// to the recorded spellings
+	type segment struct{ start, end, offset int }
+
+	var segments []segment
+	var kept [][2]int
+	extraStarts := make(map[int]bool)
+	offset := s.start
+
+	for i, child := range s.children {
+		segments = append(segments, segment{len(region), len(region) + child.start - offset, offset})
+		region = append(region, w.data[offset:child.start]...)
+		start := len(region)
+
+		switch {
+		case child.extras:
+			if jsontree.Object == child.node.Kind {
+				for j, extra := range child.node.Members {
+					if 0 < j {
+						region = append(region, ',')
+					}
+
+					extraStarts[len(region)] = true
+					region = append(region, w.data[extra.KeyStart:extra.Value.End]...)
+				}
+			}
+		case child.opaque:
+			region = append(region, w.placeholderFor(i)...)
+		default:
+			region = append(region, child.transform(w)...)
+		}
+
+		kept = append(kept, [2]int{start, len(region)})
+		offset = child.end
+	}
+
+	segments = append(segments, segment{len(region), len(region) + s.end - offset, offset})
+	region = append(region, w.data[offset:s.end]...)
+
+	source := newTrace(region)
+
+	if nil == source.root {
+		return transformBytes(w.ctx, region, w.direction, s.transformers)
+	}
+
+	pins := make(map[*jsontree.Member]string)
+	extras := make(map[*jsontree.Member]bool)
+
+	source.walkMembers(source.root, func(member *jsontree.Member) {
+		for _, k := range kept {
+			if k[0] <= member.KeyStart && member.KeyStart < k[1] {
+				pins[member] = member.Key
+				extras[member] = extraStarts[member.KeyStart]
+
+				return
+			}
+		}
+
+		for _, seg := range segments {
+			if seg.start <= member.KeyStart && member.KeyStart < seg.end {
+				if spelling, isRecorded := w.respellings[seg.offset+member.KeyStart-seg.start]; isRecorded {
+					pins[member] = spelling
+				}
+			}
+		}
+	})
+
+	transformed := dropExtras(w.run(source, s.transformers, pins), extras)
+
+	for i, child := range s.children {
+		if child.opaque {
+			transformed = bytes.Replace(transformed, w.placeholderFor(i), w.data[child.start:child.end], -1)
+		}
+	}
+
+	return transformed
+}
+
+// subtreeSearch is a structure defining the result of searching a type for
+// nested values that have their own transformations.
+type subtreeSearch struct {
+	// static is whether values of the type may contain such nested values,
+	// regardless of the dynamic types of any interfaces within them
+	static bool
+
+	// dynamic is whether values of the type may contain interfaces, whose
+	// dynamic values may contain such nested values
+	dynamic bool
+}
+
+// subtreeSearchCaches cache the searches of codecs without a type registry,
+// whose searches only depend on whether they treat values that encode or decode
+// their own JSON as opaque, so that they're shared by the codecs built for each
+// call of the package-level functions.
+var subtreeSearchCaches [2]sync.Map // map[reflect.Type]subtreeSearch
+
+// hasSubtrees takes a type and returns whether values of the type may contain
+// nested values that have their own transformations, in which case the JSON of
+// those values must be walked, regardless of the dynamic types of any
+// interfaces within them.
+func (c *Codec) hasSubtrees(t reflect.Type) bool {
+	return nil != t && c.search(t).static
+}
+
+// valueHasSubtrees takes a value and returns whether the value contains nested
+// values that have their own transformations, in which case the JSON of the
+// value must be walked, including the values within its interfaces.
+func (c *Codec) valueHasSubtrees(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+
+	if search := c.search(v.Type()); search.static || !search.dynamic {
+		return search.static
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		return !v.IsNil() && c.valueHasSubtrees(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if c.valueHasSubtrees(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Map:
+		// Reuse a single value for the map's values, to avoid copying each
+		element := reflect.New(v.Type().Elem()).Elem()
+
+		for iter := v.MapRange(); iter.Next(); {
+			if element.SetIterValue(iter); c.valueHasSubtrees(element) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for _, f := range typeFields(v.Type()) {
+			if c.valueHasSubtrees(fieldByIndex(v, f.index)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// search takes a type and returns the cached search of the type for nested
+// values that have their own transformations, searching the type if it hasn't
+// been searched yet.
+func (c *Codec) search(t reflect.Type) subtreeSearch {
+	cache := &c.searches
+
+	switch {
+	case 0 < len(c.types):
+	case c.opaqueMarshalers:
+		cache = &subtreeSearchCaches[1]
+	default:
+		cache = &subtreeSearchCaches[0]
+	}
+
+	if cached, isCached := cache.Load(t); isCached {
+		return cached.(subtreeSearch)
+	}
+
+	var search subtreeSearch
+	c.searchSubtrees(t, &search, make(map[reflect.Type]bool))
+
+	cached, _ := cache.LoadOrStore(t, search)
+
+	return cached.(subtreeSearch)
+}
+
+// searchSubtrees takes a type, the search to record the findings of searching
+// the type in, and the set of the types already visited by the search.
+func (c *Codec) searchSubtrees(t reflect.Type, search *subtreeSearch, visited map[reflect.Type]bool) {
+	if visited[t] || search.static {
+		return
+	}
+
+	visited[t] = true
+
+	if _, hasOwn := c.typeTransformers(t); hasOwn || implements(t, isolatedType) {
+		search.static = true
+
+		return
+	}
+
+	if c.opaqueMarshalers && implementsEither(t, marshalerType, unmarshalerType) {
+		search.static = true
+
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		search.dynamic = true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		c.searchSubtrees(t.Elem(), search, visited)
+	case reflect.Struct:
+		for _, f := range typeFields(t) {
+			c.searchSubtrees(f.typ, search, visited)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package conjson
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rican7/conjson/transform"
+)
+
+type secretVendorModel struct {
+	Password  string
+	ApiToken  string
+	Email     string
+	Secret    *string
+	Count     int64
+	CreatedAt time.Time
+}
+
+type secretOwnerModel struct {
+	ID       int
+	Password string
+	Vendor   secretVendorModel
+}
+
+func (secretVendorModel) JSONTransformers() []transform.Transformer {
+	return []transform.Transformer{transform.CamelCaseKeys(false)}
+}
+
+var testSecretOwnerModel = secretOwnerModel{
+	ID:       1,
+	Password: "p1",
+	Vendor: secretVendorModel{
+		Password:  "p2",
+		ApiToken:  "tok",
+		Email:     "e",
+		Count:     9007199254740993,
+		CreatedAt: time.Unix(0, 0).UTC(),
+	},
+}
+
+func TestCodec_AssertValueTransformersSeeNestedConventionValues(t *testing.T) {
+	for _, testCase := range []struct {
+		transformer  transform.Transformer
+		expectedJSON string
+	}{
+		{
+			transform.Redact([]string{"password", "*_token"}, []byte(`"x"`)),
+			`{"id":1,"password":"x","vendor":{"password":"x","apiToken":"x","email":"e","secret":null,"count":9007199254740993,"createdAt":"1970-01-01T00:00:00Z"}}`,
+		},
+		{
+			transform.Project("id", "vendor.email"),
+			`{"id":1,"vendor":{"email":"e"}}`,
+		},
+		{
+			transform.Exclude("password", "vendor.password", "vendor.api_token"),
+			`{"id":1,"vendor":{"email":"e","secret":null,"count":9007199254740993,"createdAt":"1970-01-01T00:00:00Z"}}`,
+		},
+		{
+			transform.DropNulls(),
+			`{"id":1,"password":"p1","vendor":{"password":"p2","apiToken":"tok","email":"e","count":9007199254740993,"createdAt":"1970-01-01T00:00:00Z"}}`,
+		},
+		{
+			transform.OmitEmpty(transform.AllEmpty),
+			`{"id":1,"password":"p1","vendor":{"password":"p2","apiToken":"tok","email":"e","count":9007199254740993,"createdAt":"1970-01-01T00:00:00Z"}}`,
+		},
+		{
+			transform.NumberStrings(transform.Keys("count")),
+			`{"id":1,"password":"p1","vendor":{"password":"p2","apiToken":"tok","email":"e","secret":null,"count":"9007199254740993","createdAt":"1970-01-01T00:00:00Z"}}`,
+		},
+		{
+			transform.Times(transform.Keys("created_at"), transform.RFC3339, transform.UnixSeconds),
+			`{"id":1,"password":"p1","vendor":{"password":"p2","apiToken":"tok","email":"e","secret":null,"count":9007199254740993,"createdAt":0}}`,
+		},
+	} {
+		output, err := Marshal(testSecretOwnerModel, transform.ConventionalKeys(), testCase.transformer)
+
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if testCase.expectedJSON != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, testCase.expectedJSON)
+		}
+	}
+}
+
+func TestCodec_AssertValueTransformersSeeNestedIsolatedValues(t *testing.T) {
+	type vendorModel struct {
+		Password string
+		ApiToken string
+	}
+
+	type model struct {
+		Password   string
+		Registered vendorModel
+		Isolated   Value[vendorModel, camelCaseKeys]
+	}
+
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys(), transform.Redact([]string{"password", "*_token"}, []byte(`"x"`))),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+	)
+
+	value := model{"p1", vendorModel{"p2", "tok"}, NewValue[camelCaseKeys](vendorModel{"p3", "tok"})}
+
+	const expectedJSON = `{"password":"x","registered":{"password":"x","apiToken":"x"},"isolated":{"password":"x","apiToken":"x"}}`
+
+	if output, _ := codec.Marshal(value); expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+}
+
+func TestCodec_AssertValueTransformersSeeNestedConventionValuesWhenUnmarshaling(t *testing.T) {
+	codec := NewCodec(WithTransformers(
+		transform.ConventionalKeys(),
+		transform.NumberStrings(transform.Keys("count")),
+		transform.Times(transform.Keys("created_at"), transform.RFC3339, transform.UnixSeconds),
+	))
+
+	const inputJSON = `{"id":1,"password":"p1","vendor":{"password":"p2","apiToken":"tok","email":"e","count":"9007199254740993","createdAt":0}}`
+
+	var decoded secretOwnerModel
+	if err := codec.Unmarshal([]byte(inputJSON), &decoded); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if !reflect.DeepEqual(testSecretOwnerModel, decoded) {
+			t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, testSecretOwnerModel)
+		}
+	}
+}
+
+func TestCodec_AssertIsolatedValuesArentTransformed(t *testing.T) {
+	type model struct {
+		OuterKey string
+		Inner    Value[map[string]string, camelCaseKeys]
+	}
+
+	value := model{
+		OuterKey: "outer",
+		Inner:    NewValue[camelCaseKeys](map[string]string{"inner_key": "inner"}),
+	}
+
+	const expectedJSON = `{"outer_key":"outer","inner":{"innerKey":"inner"}}`
+
+	if output, _ := Marshal(value, transform.ConventionalKeys()); expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	decoded := model{}
+	if err := NewUnmarshaler(&decoded, transform.ConventionalKeys()).UnmarshalJSON([]byte(expectedJSON)); true {
+		if nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+
+		if "outer" != decoded.OuterKey || "inner" != decoded.Inner.Value["innerKey"] {
+			t.Errorf("decoded was `%+v`, when expected to be `%+v`", decoded, value)
+		}
+	}
+}
+
+func TestCodec_AssertPlaceholdersAreUnique(t *testing.T) {
+	codec := NewCodec(
+		WithTransformers(transform.ConventionalKeys()),
+		WithTypeTransformers[vendorModel](transform.CamelCaseKeys(false)),
+	)
+
+	// Values that look like the placeholders of nested subtrees
+	model := domainModel{
+		OwnerName: "\x000\x000\x00",
+		Vendor:    vendorModel{"\x000\x000\x00", "\x001\x000\x00"},
+	}
+
+	output, err := codec.Marshal(model)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expected := `"vendor":{"accountID":"\u00000\u00000\u0000","displayName":"\u00001\u00000\u0000"}`; !strings.Contains(string(output), expected) {
+		t.Errorf("Output %s doesn't contain expected %s", output, expected)
+	}
+
+	if expected := `"owner_name":"\u00000\u00000\u0000"`; !strings.Contains(string(output), expected) {
+		t.Errorf("Output %s doesn't contain expected %s", output, expected)
+	}
+}
+
+func TestCodec_AssertOnlyValuesWithSubtreesAreWalked(t *testing.T) {
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys()))
+
+	for _, testCase := range []struct {
+		value    interface{}
+		expected bool
+	}{
+		{map[string]interface{}{"a": 1, "b": []interface{}{"c", map[string]interface{}{"d": true}}}, false},
+		{map[string]interface{}{"a": []interface{}{providerModel{}}}, true},
+		{[]interface{}{nil, &pointerProviderModel{}}, true},
+		{providerParentModel{}, true},
+		{testCodecModel, false},
+		{nil, false},
+	} {
+		if walked := codec.valueHasSubtrees(reflect.ValueOf(testCase.value)); testCase.expected != walked {
+			t.Errorf("valueHasSubtrees(%#v) was `%t`, when expected to be `%t`", testCase.value, walked, testCase.expected)
+		}
+	}
+
+	// Searches are cached per type
+	if search := codec.search(reflect.TypeOf(providerParentModel{})); !search.static || search.dynamic {
+		t.Errorf("search was `%+v`, when expected to be static only", search)
+	}
+}
+
+func TestCodec_AssertFormattersRunOnTheFinalDocument(t *testing.T) {
+	type nestedModel struct {
+		Zulu  float64
+		Alpha string
+	}
+
+	type model struct {
+		Zed    int
+		Nested nestedModel
+		Extra  Extras
+	}
+
+	value := model{1, nestedModel{2.50, "a"}, Extras{"b": []byte(`2.50`), "a": []byte(` "x" `)}}
+
+	for _, testCase := range []struct {
+		codec        *Codec
+		expectedJSON string
+	}{
+		{
+			NewCodec(
+				WithTransformers(transform.ConventionalKeys(), transform.Canonical()),
+				WithTypeTransformers[nestedModel](transform.CamelCaseKeys(false)),
+			),
+			`{"a":"x","b":2.5,"nested":{"alpha":"a","zulu":2.5},"zed":1}`,
+		},
+		{
+			NewCodec(
+				WithTransformers(transform.ConventionalKeys(), transform.Compact()),
+				WithTypeTransformers[nestedModel](transform.CamelCaseKeys(false), transform.Indent("", "  ")),
+			),
+			`{"zed":1,"nested":{"zulu":2.5,"alpha":"a"},"a":"x","b":2.50}`,
+		},
+	} {
+		if output, _ := testCase.codec.Marshal(value); testCase.expectedJSON != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, testCase.expectedJSON)
+		}
+	}
+}
+
+func TestCodec_AssertWrappedFormattersFormatNestedValues(t *testing.T) {
+	const expectedJSON = `{"id":1,"password":"p1","vendor":{"apiToken":"tok","count":9007199254740992,"createdAt":"1970-01-01T00:00:00Z","email":"e","password":"p2","secret":null}}`
+
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys(), transform.OnlyForDirection(transform.Marshal, transform.Canonical())))
+
+	if output, _ := codec.Marshal(testSecretOwnerModel); expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	output, err := MarshalContext(
+		context.Background(),
+		testSecretOwnerModel,
+		transform.IgnoreContext(transform.ConventionalKeys()),
+		transform.IgnoreContext(transform.Canonical()),
+	)
+
+	if nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+}
+
+func TestCodec_AssertProbesAreCachedPerCodec(t *testing.T) {
+	var seen []string
+
+	logging := func(data []byte, direction transform.Direction) []byte {
+		seen = append(seen, string(data))
+
+		return data
+	}
+
+	codec := NewCodec(WithTransformers(transform.ConventionalKeys(), logging), ExactFieldNames())
+
+	for i := 0; i < 3; i++ {
+		var decoded codecModel
+		if err := codec.Unmarshal([]byte(`{"image_url":"a","is_active":true}`), &decoded); nil != err {
+			t.Errorf("Unexpected error (%T) %q", err, err)
+		}
+	}
+
+	// Each decode, and a probe of each of the two fields' names
+	if expected := 3 + 2; expected != len(seen) {
+		t.Errorf("logging transformer saw `%v`, when expected to see `%d` documents", seen, expected)
+	}
+
+	// Unmarshaling without checking the fields never probes
+	seen = nil
+	codec = NewCodec(WithTransformers(transform.ConventionalKeys(), logging))
+
+	var decoded secretOwnerModel
+	if _, err := codec.UnmarshalPresence([]byte(`{"id":1,"vendor":{"apiToken":"tok"}}`), &decoded); nil != err {
+		t.Errorf("Unexpected error (%T) %q", err, err)
+	}
+
+	if expected := []string{`{"id":1,"vendor":{"apiToken":"tok"}}`}; !reflect.DeepEqual(expected, seen) {
+		t.Errorf("logging transformer saw `%v`, when expected to see `%v`", seen, expected)
+	}
+}
@@ -0,0 +1,526 @@
+package conjson
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+	"github.com/Rican7/conjson/transform"
+)
+
+// trace is a structure defining a JSON document that was transformed from a
+// source document, along with the values and members of the source document
+// that the document's values and members were transformed from, as far as
+// they're known.
+//
+// A trace is what lets the parts of a transformed document be related back to
+// the original document, regardless of how the transformers moved, reordered,
+// or dropped them, without the transformers having to be run on anything but
+// the document itself.
+type trace struct {
+	data []byte
+
+	// root is the root node of the document, or nil if the document isn't
+	// valid JSON
+	root *jsontree.Node
+
+	// isSource is whether the document is its own source document
+	isSource bool
+
+	// origins are the values of the source document, by the values of the
+	// document that they were transformed into
+	origins map[*jsontree.Node]*jsontree.Node
+
+	// memberOrigins are the members of the source document, by the members of
+	// the document that they were transformed into
+	memberOrigins map[*jsontree.Member]*jsontree.Member
+}
+
+// edit is a structure defining a replacement of a region of a JSON document.
+type edit struct {
+	start, end  int
+	replacement []byte
+}
+
+// newTrace takes a JSON document and returns a trace of the document as its
+// own source document.
+func newTrace(data []byte) *trace {
+	return &trace{data: data, root: parseOrNil(data), isSource: true}
+}
+
+// parseOrNil takes a JSON document and returns the root node of the parsed
+// document, or nil if the document isn't valid JSON.
+func parseOrNil(data []byte) *jsontree.Node {
+	root, err := jsontree.Parse(data)
+
+	if nil != err {
+		return nil
+	}
+
+	return root
+}
+
+// origin takes a value of the document and returns the value of the source
+// document that it was transformed from, or nil if it isn't known.
+func (t *trace) origin(node *jsontree.Node) *jsontree.Node {
+	if t.isSource {
+		return node
+	}
+
+	return t.origins[node]
+}
+
+// memberOrigin takes a member of the document and returns the member of the
+// source document that it was transformed from, or nil if it isn't known.
+func (t *trace) memberOrigin(member *jsontree.Member) *jsontree.Member {
+	if t.isSource {
+		return member
+	}
+
+	return t.memberOrigins[member]
+}
+
+// run takes the trace of a document, a list of transformers, and a set of keys
+// by the members of the trace's source document, and returns the trace of the
+// result of running each of the transformers, in order, on the document.
+//
+// The members transformed from the members that have a key in the set are
+// respelled with that key after each transformer has run, so that none of the
+// transformers is able to change those keys, and so that every transformer
+// that follows sees them as they are in the end.
+func (w *walker) run(source *trace, transformers []transform.ContextTransformer, pins map[*jsontree.Member]string) *trace {
+	current := source.pin(pins)
+
+	for _, transformer := range transformers {
+		current = w.step(current, transformer, w.direction).pin(pins)
+	}
+
+	return current
+}
+
+// step takes the trace of a document, a transformer, and a direction, and
+// returns the trace of the result of running the transformer on the document
+// in the given direction.
+func (w *walker) step(current *trace, transformer transform.ContextTransformer, direction transform.Direction) *trace {
+	// Pass a copy, as the trace's nodes refer to the data
+	data := transformer(w.ctx, append([]byte(nil), current.data...), direction)
+
+	if bytes.Equal(current.data, data) {
+		return current
+	}
+
+	next := &trace{
+		data:          data,
+		root:          parseOrNil(data),
+		origins:       make(map[*jsontree.Node]*jsontree.Node),
+		memberOrigins: make(map[*jsontree.Member]*jsontree.Member),
+	}
+
+	if nil != current.root && nil != next.root {
+		(&aligner{current, next}).align(current.root, next.root)
+	}
+
+	return next
+}
+
+// find takes a member of the trace's source document and returns the member of
+// the document that was transformed from it, or nil if it isn't known.
+func (t *trace) find(original *jsontree.Member) *jsontree.Member {
+	var found *jsontree.Member
+
+	if nil != t.root {
+		t.walkMembers(t.root, func(member *jsontree.Member) {
+			if nil == found && original == t.memberOrigin(member) {
+				found = member
+			}
+		})
+	}
+
+	return found
+}
+
+// pin takes a set of keys by the members of the trace's source document and
+// returns the trace of the document with the members transformed from those
+// members respelled with their keys.
+func (t *trace) pin(pins map[*jsontree.Member]string) *trace {
+	if 0 == len(pins) || nil == t.root {
+		return t
+	}
+
+	var edits []edit
+
+	t.walkMembers(t.root, func(member *jsontree.Member) {
+		if key, isPinned := pins[t.memberOrigin(member)]; isPinned && key != member.Key {
+			edits = append(edits, edit{member.KeyStart, member.KeyEnd, quote(key)})
+		}
+	})
+
+	if 0 == len(edits) {
+		return t
+	}
+
+	return t.derive(t.root, edits)
+}
+
+// restore takes a value of the document and returns the trace of the value,
+// as a document of its own, with the members whose original member is known
+// respelled with their original keys.
+func (t *trace) restore(node *jsontree.Node) *trace {
+	var edits []edit
+
+	t.walkMembers(node, func(member *jsontree.Member) {
+		if original := t.memberOrigin(member); nil != original && original.Key != member.Key {
+			edits = append(edits, edit{member.KeyStart, member.KeyEnd, quote(original.Key)})
+		}
+	})
+
+	return t.derive(node, edits)
+}
+
+// derive takes a value of the document and a list of edits of the value's
+// keys, and returns the trace of the value, as a document of its own, with the
+// edits applied, keeping the origins of the value's values and members.
+func (t *trace) derive(node *jsontree.Node, edits []edit) *trace {
+	derived := &trace{
+		data:          applyEdits(t.data, node.Start, node.End, edits),
+		origins:       make(map[*jsontree.Node]*jsontree.Node),
+		memberOrigins: make(map[*jsontree.Member]*jsontree.Member),
+	}
+
+	if derived.root = parseOrNil(derived.data); nil != derived.root {
+		t.relate(node, derived.root, derived)
+	}
+
+	return derived
+}
+
+// relate takes a value of the document, the same value in a derived document
+// whose structure is the same, and the derived document's trace, and records
+// the origins of the value and of the values and members within it in the
+// derived document's trace.
+func (t *trace) relate(node, derivedNode *jsontree.Node, derived *trace) {
+	if origin := t.origin(node); nil != origin {
+		derived.origins[derivedNode] = origin
+	}
+
+	switch node.Kind {
+	case jsontree.Object:
+		for i := range node.Members {
+			if origin := t.memberOrigin(&node.Members[i]); nil != origin {
+				derived.memberOrigins[&derivedNode.Members[i]] = origin
+			}
+
+			t.relate(node.Members[i].Value, derivedNode.Members[i].Value, derived)
+		}
+	case jsontree.Array:
+		for i, element := range node.Elements {
+			t.relate(element, derivedNode.Elements[i], derived)
+		}
+	}
+}
+
+// walkMembers takes a value of the document and a function, and calls the
+// function with each member of each object within the value, in order.
+func (t *trace) walkMembers(node *jsontree.Node, fn func(*jsontree.Member)) {
+	switch node.Kind {
+	case jsontree.Object:
+		for i := range node.Members {
+			fn(&node.Members[i])
+			t.walkMembers(node.Members[i].Value, fn)
+		}
+	case jsontree.Array:
+		for _, element := range node.Elements {
+			t.walkMembers(element, fn)
+		}
+	}
+}
+
+// applyEdits takes a document, the offsets of the start and the end of a
+// region of the document, and a list of edits within that region, and returns
+// the region with the edits applied. Overlapping edits are merged.
+func applyEdits(data []byte, start, end int, edits []edit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+
+	var edited []byte
+	offset := start
+
+	for _, e := range edits {
+		if e.start < offset {
+			// Merge the edit into the previous one
+			e.start = offset
+		}
+
+		if e.end < e.start {
+			continue
+		}
+
+		edited = append(edited, data[offset:e.start]...)
+		edited = append(edited, e.replacement...)
+		offset = e.end
+	}
+
+	return append(edited, data[offset:end]...)
+}
+
+// aligner is a structure that relates the values and members of a document to
+// those of the document that a single transformer transformed it into.
+type aligner struct {
+	prev, next *trace
+}
+
+// shape defines the broad kind of a JSON value, which a transformer that only
+// changes values, such as by quoting numbers, doesn't change.
+type shape uint8
+
+const (
+	scalarShape shape = iota
+	objectShape
+	arrayShape
+)
+
+// shapeOf takes a node and returns the shape of its value.
+func shapeOf(node *jsontree.Node) shape {
+	switch node.Kind {
+	case jsontree.Object:
+		return objectShape
+	case jsontree.Array:
+		return arrayShape
+	}
+
+	return scalarShape
+}
+
+// align takes a value of the previous document and a value of the next
+// document, and relates them and the values and members within them, should
+// they correspond. Otherwise, the value of the next document is searched for a
+// value that the previous value was wrapped into, and the previous value for a
+// value that was unwrapped into the next value, such as by transform.Envelope.
+func (a *aligner) align(prev, next *jsontree.Node) {
+	if a.match(prev, next) {
+		return
+	}
+
+	for _, inner := range innerNodes(next) {
+		if isWrapped(prev, inner) {
+			a.match(prev, inner)
+
+			return
+		}
+	}
+
+	for _, inner := range innerNodes(prev) {
+		if isWrapped(inner, next) {
+			a.match(inner, next)
+
+			return
+		}
+	}
+}
+
+// match takes a value of the previous document and a value of the next
+// document, and returns whether they correspond, relating them and the values
+// and members within them if they do.
+func (a *aligner) match(prev, next *jsontree.Node) bool {
+	if shapeOf(prev) != shapeOf(next) {
+		return false
+	}
+
+	switch next.Kind {
+	case jsontree.Object:
+		pairs := pairMembers(prev, next)
+
+		if nil == pairs {
+			return false
+		}
+
+		for i, j := range pairs {
+			if origin := a.prev.memberOrigin(&prev.Members[j]); nil != origin {
+				a.next.memberOrigins[&next.Members[i]] = origin
+			}
+
+			a.align(prev.Members[j].Value, next.Members[i].Value)
+		}
+	case jsontree.Array:
+		pairs := pairElements(prev, next)
+
+		if nil == pairs {
+			return false
+		}
+
+		for i, j := range pairs {
+			a.align(prev.Elements[j], next.Elements[i])
+		}
+	}
+
+	if origin := a.prev.origin(prev); nil != origin {
+		a.next.origins[next] = origin
+	}
+
+	return true
+}
+
+// pairMembers takes an object of the previous document and an object of the
+// next document, and returns the index of the member of the previous object
+// that each member of the next object corresponds to, or nil if they can't be
+// paired.
+//
+// Members are paired by their keys when the next object's keys are all keys
+// of the previous object, as when members are only dropped or reordered, and
+// by their positions when both objects have the same number of members of the
+// same shapes, as when keys are only renamed.
+func pairMembers(prev, next *jsontree.Node) []int {
+	if hasKeysOf(prev, next) {
+		indexes := make(map[string][]int, len(prev.Members))
+
+		for j, member := range prev.Members {
+			indexes[member.Key] = append(indexes[member.Key], j)
+		}
+
+		pairs := make([]int, len(next.Members))
+
+		for i, member := range next.Members {
+			pairs[i] = indexes[member.Key][0]
+			indexes[member.Key] = indexes[member.Key][1:]
+		}
+
+		return pairs
+	}
+
+	if len(prev.Members) != len(next.Members) {
+		return nil
+	}
+
+	pairs := make([]int, len(next.Members))
+
+	for i, member := range next.Members {
+		if shapeOf(prev.Members[i].Value) != shapeOf(member.Value) {
+			return nil
+		}
+
+		pairs[i] = i
+	}
+
+	return pairs
+}
+
+// hasKeysOf takes an object of the previous document and an object of the next
+// document, and returns whether every key of the next object is a key of the
+// previous object, at least as many times.
+func hasKeysOf(prev, next *jsontree.Node) bool {
+	counts := make(map[string]int, len(prev.Members))
+
+	for _, member := range prev.Members {
+		counts[member.Key]++
+	}
+
+	for _, member := range next.Members {
+		if counts[member.Key]--; 0 > counts[member.Key] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pairElements takes an array of the previous document and an array of the
+// next document, and returns the index of the element of the previous array
+// that each element of the next array corresponds to, or nil if they can't be
+// paired.
+//
+// Elements are paired by their positions when both arrays have the same
+// length, and otherwise in order, skipping the elements of the previous array
+// that were dropped, such as by transform.DropNulls.
+func pairElements(prev, next *jsontree.Node) []int {
+	pairs := make([]int, len(next.Elements))
+
+	if len(prev.Elements) == len(next.Elements) {
+		for i := range pairs {
+			pairs[i] = i
+		}
+
+		return pairs
+	}
+
+	j := 0
+
+	for i, element := range next.Elements {
+		for j < len(prev.Elements) && !isKept(prev.Elements[j], element) {
+			j++
+		}
+
+		if j == len(prev.Elements) {
+			return nil
+		}
+
+		pairs[i] = j
+		j++
+	}
+
+	return pairs
+}
+
+// isKept takes an element of an array of the previous document and an element
+// of an array of the next document, and returns whether the next element may
+// be the previous element, kept while other elements were dropped.
+func isKept(prev, next *jsontree.Node) bool {
+	if shapeOf(prev) != shapeOf(next) {
+		return false
+	}
+
+	if jsontree.Object == next.Kind {
+		return hasKeysOf(prev, next) || len(prev.Members) == len(next.Members)
+	}
+
+	return true
+}
+
+// isWrapped takes a value of the previous document and a value of the next
+// document, and returns whether the next value is the previous value, once
+// wrapped into or unwrapped from another value.
+//
+// Only non-empty objects and arrays are considered, as they're the only values
+// that can be told apart from others.
+func isWrapped(prev, next *jsontree.Node) bool {
+	switch {
+	case jsontree.Object == prev.Kind && jsontree.Object == next.Kind:
+		return 0 < len(next.Members) && hasKeysOf(prev, next)
+	case jsontree.Array == prev.Kind && jsontree.Array == next.Kind:
+		return 0 < len(next.Elements) && len(prev.Elements) == len(next.Elements)
+	}
+
+	return false
+}
+
+// innerNodes takes a node and returns the values within it, up to two levels
+// deep, in breadth-first order.
+func innerNodes(node *jsontree.Node) []*jsontree.Node {
+	children := childNodes(node)
+	inner := append([]*jsontree.Node(nil), children...)
+
+	for _, child := range children {
+		inner = append(inner, childNodes(child)...)
+	}
+
+	return inner
+}
+
+// childNodes takes a node and returns the values of its members or its
+// elements.
+func childNodes(node *jsontree.Node) []*jsontree.Node {
+	switch node.Kind {
+	case jsontree.Object:
+		children := make([]*jsontree.Node, len(node.Members))
+
+		for i, member := range node.Members {
+			children[i] = member.Value
+		}
+
+		return children
+	case jsontree.Array:
+		return node.Elements
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// Canonical returns a Transformer that, for the "Marshal" direction only,
+// converts the transformed data to its canonical form, as defined by the JSON
+// Canonicalization Scheme (JCS) of RFC 8785, so that equal data always results
+// in the exact same bytes, such as for signing or hashing.
+//
+// In the canonical form, object members are sorted by their keys, numbers are
+// formatted the same as ECMAScript formats IEEE 754 double precision numbers,
+// strings use the minimal escaping, and there's no whitespace between tokens.
+//
+// As the canonical form depends on the final keys of the data, the Transformer
+// should run after any transformers that convert keys. The JSON of nested
+// values that are transformed separately, such as for types with their own
+// convention, is part of the data that it sees, with its final keys.
+//
+// https://www.rfc-editor.org/rfc/rfc8785
+func Canonical() Transformer {
+	return func(data []byte, direction Direction) []byte {
+		if Marshal != direction {
+			return data
+		}
+
+		root, err := jsontree.Parse(data)
+
+		if nil != err {
+			return data
+		}
+
+		return appendCanonical(nil, root, data)
+	}
+}
+
+// appendCanonical takes a buffer, a node, and the data that the node was
+// parsed from, and returns the buffer with the canonical form of the node's
+// value appended to it.
+func appendCanonical(buffer []byte, node *jsontree.Node, data []byte) []byte {
+	switch node.Kind {
+	case jsontree.Object:
+		members := append([]jsontree.Member(nil), node.Members...)
+
+		// Sort by the UTF-16 code units of the keys, as RFC 8785 requires
+		sort.SliceStable(members, func(i, j int) bool {
+			return lessUTF16(members[i].Key, members[j].Key)
+		})
+
+		buffer = append(buffer, '{')
+
+		for i, member := range members {
+			if 0 < i {
+				buffer = append(buffer, ',')
+			}
+
+			buffer = appendCanonicalString(buffer, member.Key)
+			buffer = append(buffer, ':')
+			buffer = appendCanonical(buffer, member.Value, data)
+		}
+
+		return append(buffer, '}')
+	case jsontree.Array:
+		buffer = append(buffer, '[')
+
+		for i, element := range node.Elements {
+			if 0 < i {
+				buffer = append(buffer, ',')
+			}
+
+			buffer = appendCanonical(buffer, element, data)
+		}
+
+		return append(buffer, ']')
+	case jsontree.String:
+		raw := node.Raw(data)
+
+		// Strings without escapes are already in their canonical form
+		if !bytes.ContainsRune(raw, '\\') {
+			return append(buffer, raw...)
+		}
+
+		var decoded string
+
+		if err := json.Unmarshal(raw, &decoded); nil != err {
+			return append(buffer, raw...)
+		}
+
+		return appendCanonicalString(buffer, decoded)
+	case jsontree.Number:
+		return append(buffer, canonicalNumber(node.Raw(data))...)
+	}
+
+	return append(buffer, node.Raw(data)...)
+}
+
+// appendCanonicalString takes a buffer and a string and returns the buffer
+// with the string appended as a JSON string with the minimal escaping: only
+// quotation marks, reverse solidi, and control characters are escaped.
+func appendCanonicalString(buffer []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	buffer = append(buffer, '"')
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case '"' == c || '\\' == c:
+			buffer = append(buffer, '\\', c)
+		case '\b' == c:
+			buffer = append(buffer, '\\', 'b')
+		case '\f' == c:
+			buffer = append(buffer, '\\', 'f')
+		case '\n' == c:
+			buffer = append(buffer, '\\', 'n')
+		case '\r' == c:
+			buffer = append(buffer, '\\', 'r')
+		case '\t' == c:
+			buffer = append(buffer, '\\', 't')
+		case c < 0x20:
+			buffer = append(buffer, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+		default:
+			buffer = append(buffer, c)
+		}
+	}
+
+	return append(buffer, '"')
+}
+
+// canonicalNumber takes a JSON number and returns it formatted the same as
+// ECMAScript formats an IEEE 754 double precision number. Numbers that can't
+// be represented as a double are returned as-is.
+func canonicalNumber(number []byte) []byte {
+	value, err := strconv.ParseFloat(string(number), 64)
+
+	if nil != err {
+		return number
+	}
+
+	if 0 == value {
+		return []byte("0")
+	}
+
+	if abs := math.Abs(value); abs < 1e-6 || abs >= 1e21 {
+		formatted := strconv.FormatFloat(value, 'e', -1, 64)
+
+		// ECMAScript doesn't pad the exponent with a leading zero
+		if n := len(formatted); '0' == formatted[n-2] && 'e' == formatted[n-4] {
+			formatted = formatted[:n-2] + formatted[n-1:]
+		}
+
+		return []byte(formatted)
+	}
+
+	return []byte(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// lessUTF16 takes two strings and returns whether the first string sorts
+// before the second, comparing their UTF-16 code units.
+func lessUTF16(a, b string) bool {
+	unitsA, unitsB := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+
+	for i := 0; i < len(unitsA) && i < len(unitsB); i++ {
+		if unitsA[i] != unitsB[i] {
+			return unitsA[i] < unitsB[i]
+		}
+	}
+
+	return len(unitsA) < len(unitsB)
+}
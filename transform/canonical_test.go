@@ -0,0 +1,53 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	const inputJSON = `
+	{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001, -0, 10, 1e-7, 0.000001],
+		"string": "€$\u000F\u000aA'B\"\\\\\"\/",
+		"literals": [null, true, false],
+		"€": "Euro Sign",
+		"\r": "Carriage Return",
+		"1": "One",
+		"\u0080": "Control",
+		"😀": "Smiley",
+		"ö": "Latin Small Letter O With Diaeresis",
+		"דּ": "Hebrew Letter Dalet With Dagesh",
+		"html": "<a & b>"
+	}
+	`
+
+	const expectedJSON = `{"\r":"Carriage Return","1":"One","html":"<a & b>",` +
+		`"literals":[null,true,false],` +
+		`"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27,0,10,1e-7,0.000001],` +
+		`"string":"€$\u000f\nA'B\"\\\\\"/",` +
+		"\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\"," +
+		"\"😀\":\"Smiley\",\"דּ\":\"Hebrew Letter Dalet With Dagesh\"}"
+
+	trans := Canonical()
+
+	if output := trans([]byte(inputJSON), Marshal); expectedJSON != string(output) {
+		t.Errorf("Marshal output of %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	if output := trans([]byte(inputJSON), Unmarshal); inputJSON != string(output) {
+		t.Errorf("Unmarshal output of %s doesn't match expected %s", output, inputJSON)
+	}
+
+	if output := trans([]byte(`{"invalid"`), Marshal); `{"invalid"` != string(output) {
+		t.Errorf("Marshal output of %s doesn't match expected %s", output, `{"invalid"`)
+	}
+}
+
+func TestCanonical_AssertComposesAfterKeyTransformers(t *testing.T) {
+	const inputJSON = `{"zebraName": 1, "appleName": 2.0}`
+	const expectedJSON = `{"apple_name":2,"zebra_name":1}`
+
+	if output := Bytes([]byte(inputJSON), Marshal, ConventionalKeys(), Canonical()); expectedJSON != string(output) {
+		t.Errorf("Marshal output of %s doesn't match expected %s", output, expectedJSON)
+	}
+}
@@ -0,0 +1,50 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// ConventionalValues takes a Selector and returns a Transformer that converts
+// the selected JSON string values, depending on the transformation direction,
+// between the case convention of Go constants and that of JSON data, such as
+// for the values of enums.
+//
+// For the "Marshal" direction, values are converted to `snake_case` style, so
+// that `"InProgress"` becomes `"in_progress"`. For the "Unmarshal" direction,
+// values are converted to `PascalCase` style, so that `"in_progress"` becomes
+// `"InProgress"`.
+//
+// The words of the values are found the same as they are for the keys
+// converted by ConventionalKeys. Values that aren't strings are kept as-is.
+// When a selected value is an array, its elements are converted instead.
+func ConventionalValues(selector Selector) Transformer {
+	return func(data []byte, direction Direction) []byte {
+		convert := func(node *jsontree.Node, value []byte) []byte {
+			var content string
+
+			if jsontree.String != node.Kind || nil != json.Unmarshal(value, &content) || "" == content {
+				return value
+			}
+
+			if Marshal == direction {
+				return appendCanonicalString(nil, string(camelCaseToSnakeCase([]byte(content))))
+			}
+
+			// Hyphenated values are converted as underscored values
+			converted := snakeCaseToCamelCaseWordBarrier(bytes.Replace([]byte(content), []byte("-"), []byte("_"), -1))
+
+			return appendCanonicalString(nil, string(append(bytes.ToUpper(converted[0:1]), converted[1:]...)))
+		}
+
+		return rewriteValues(data, func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			if !selector(valuePath, isMember) {
+				return nil, false
+			}
+
+			return rewriteElements(node, value, convert), true
+		}, nil)
+	}
+}
@@ -0,0 +1,26 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestConventionalValues(t *testing.T) {
+	trans := ConventionalValues(Keys("status", "*_kind"))
+
+	for _, testCase := range []struct {
+		direction      Direction
+		inputJSON      string
+		expectedOutput string
+	}{
+		{Marshal, `{"status": "InProgress", "name": "InProgress"}`, `{"status": "in_progress", "name": "InProgress"}`},
+		{Marshal, `{"status": "Done", "item_kind": ["LineItem", "Fee"]}`, `{"status": "done", "item_kind": ["line_item", "fee"]}`},
+		{Marshal, `{"status": null, "item_kind": 1}`, `{"status": null, "item_kind": 1}`},
+		{Unmarshal, `{"status": "in_progress", "name": "in_progress"}`, `{"status": "InProgress", "name": "in_progress"}`},
+		{Unmarshal, `{"status": "done", "itemKind": ["line_item", "on-hold"]}`, `{"status": "Done", "itemKind": ["LineItem", "OnHold"]}`},
+		{Unmarshal, `{"status": ""}`, `{"status": ""}`},
+	} {
+		if output := trans([]byte(testCase.inputJSON), testCase.direction); testCase.expectedOutput != string(output) {
+			t.Errorf("%s output of %s doesn't match expected %s", testCase.direction, output, testCase.expectedOutput)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package transform
+
+import (
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// EnvelopeOption defines a function that configures the envelope of JSON data.
+type EnvelopeOption func(*envelope)
+
+// envelope is a structure that holds the configuration of the envelope of JSON
+// data.
+type envelope struct {
+	key         string
+	metadataKey string
+	metadata    []byte
+	isArray     bool
+}
+
+// EnvelopeMetadata takes a key and a JSON value and returns an EnvelopeOption
+// that adds the value as a sibling of the enveloped data, under the given key,
+// such as `{"data":...,"meta":{"version":1}}`.
+//
+// The metadata must be a valid JSON value. For the "Unmarshal" direction, any
+// metadata is discarded along with the rest of the envelope.
+func EnvelopeMetadata(key string, metadata []byte) EnvelopeOption {
+	return func(e *envelope) {
+		e.metadataKey = key
+		e.metadata = metadata
+	}
+}
+
+// EnvelopeArray returns an EnvelopeOption that encloses the enveloped data in a
+// single-element array, such as `{"data":[...]}`.
+//
+// For the "Unmarshal" direction, an enveloped single-element array is
+// unwrapped to its element, while any other enveloped value is kept as-is.
+func EnvelopeArray() EnvelopeOption {
+	return func(e *envelope) {
+		e.isArray = true
+	}
+}
+
+// Envelope takes a key and a variable number of `EnvelopeOption`s and returns
+// a Transformer that wraps and unwraps JSON data in an "envelope" object.
+//
+// For the "Marshal" direction, the data is wrapped as the value of the given
+// key, so that `{"id":1}` becomes `{"data":{"id":1}}` with a "data" key. For
+// the "Unmarshal" direction, the value of the given key is unwrapped, and the
+// rest of the envelope is discarded.
+//
+// Data that isn't valid JSON, or that isn't an envelope object containing the
+// given key for the "Unmarshal" direction, is returned as-is.
+func Envelope(key string, options ...EnvelopeOption) Transformer {
+	e := &envelope{key: key}
+
+	for _, option := range options {
+		option(e)
+	}
+
+	return func(data []byte, direction Direction) []byte {
+		root, err := jsontree.Parse(data)
+
+		if nil != err {
+			return data
+		}
+
+		if Unmarshal == direction {
+			return e.unwrap(root, data)
+		}
+
+		return e.wrap(root.Raw(data))
+	}
+}
+
+// wrap takes a JSON value and returns the value wrapped in the envelope.
+func (e *envelope) wrap(value []byte) []byte {
+	wrapped := appendCanonicalString([]byte{'{'}, e.key)
+	wrapped = append(wrapped, ':')
+
+	if e.isArray {
+		wrapped = append(append(append(wrapped, '['), value...), ']')
+	} else {
+		wrapped = append(wrapped, value...)
+	}
+
+	if nil != e.metadata {
+		wrapped = appendCanonicalString(append(wrapped, ','), e.metadataKey)
+		wrapped = append(append(wrapped, ':'), e.metadata...)
+	}
+
+	return append(wrapped, '}')
+}
+
+// unwrap takes the node of an envelope and the data that the node was parsed
+// from, and returns the enveloped value.
+func (e *envelope) unwrap(node *jsontree.Node, data []byte) []byte {
+	if jsontree.Object != node.Kind {
+		return data
+	}
+
+	for _, member := range node.Members {
+		if e.key != member.Key {
+			continue
+		}
+
+		value := member.Value
+
+		if e.isArray && jsontree.Array == value.Kind && 1 == len(value.Elements) {
+			value = value.Elements[0]
+		}
+
+		return value.Raw(data)
+	}
+
+	return data
+}
@@ -0,0 +1,50 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	for _, testCase := range []struct {
+		options      []EnvelopeOption
+		inputJSON    string
+		wrappedJSON  string
+		expectedJSON string
+	}{
+		{nil, `{"id":1}`, `{"data":{"id":1}}`, `{"id":1}`},
+		{nil, ` [1, 2] `, `{"data":[1, 2]}`, `[1, 2]`},
+		{[]EnvelopeOption{EnvelopeMetadata("meta", []byte(`{"version":1}`))}, `"a"`, `{"data":"a","meta":{"version":1}}`, `"a"`},
+		{[]EnvelopeOption{EnvelopeArray()}, `{"id":1}`, `{"data":[{"id":1}]}`, `{"id":1}`},
+	} {
+		trans := Envelope("data", testCase.options...)
+
+		if output := trans([]byte(testCase.inputJSON), Marshal); testCase.wrappedJSON != string(output) {
+			t.Errorf("Marshal output of %s doesn't match expected %s", output, testCase.wrappedJSON)
+		}
+
+		if output := trans([]byte(testCase.wrappedJSON), Unmarshal); testCase.expectedJSON != string(output) {
+			t.Errorf("Unmarshal output of %s doesn't match expected %s", output, testCase.expectedJSON)
+		}
+	}
+}
+
+func TestEnvelopeUnwrap(t *testing.T) {
+	for _, testCase := range []struct {
+		options        []EnvelopeOption
+		inputJSON      string
+		expectedOutput string
+	}{
+		{nil, `{"meta": {}, "result": {"id": 1}}`, `{"id": 1}`},
+		{nil, `{"data": {"id": 1}}`, `{"data": {"id": 1}}`},
+		{nil, `["result"]`, `["result"]`},
+		{nil, `{"result":`, `{"result":`},
+		{nil, `{"result": [{"id": 1}]}`, `[{"id": 1}]`},
+		{[]EnvelopeOption{EnvelopeArray()}, `{"result": [{"id": 1}]}`, `{"id": 1}`},
+		{[]EnvelopeOption{EnvelopeArray()}, `{"result": [1, 2]}`, `[1, 2]`},
+		{[]EnvelopeOption{EnvelopeArray()}, `{"result": []}`, `[]`},
+	} {
+		if output := Envelope("result", testCase.options...)([]byte(testCase.inputJSON), Unmarshal); testCase.expectedOutput != string(output) {
+			t.Errorf("Output of %s doesn't match expected %s", output, testCase.expectedOutput)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package transform
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// ArrayNotation defines how the elements of JSON arrays are addressed in the
+// keys of flattened JSON data.
+type ArrayNotation uint8
+
+// FlattenOption defines a function that configures the flattening of JSON
+// data.
+type FlattenOption func(*flattener)
+
+// flattener is a structure that holds the configuration of the flattening of
+// JSON data.
+type flattener struct {
+	separator string
+	notation  ArrayNotation
+	maxDepth  int
+}
+
+// flatTree is a structure that holds a JSON value as it's rebuilt from the
+// members of flattened JSON data.
+type flatTree struct {
+	raw      []byte
+	keys     []string
+	members  map[string]*flatTree
+	elements map[int]*flatTree
+}
+
+// flatSegment is a structure defining a segment of a flattened key.
+type flatSegment struct {
+	key     string
+	index   int
+	isIndex bool
+
+	// start is the offset of the segment within the flattened key
+	start int
+}
+
+const (
+	// KeepArrays defines that arrays aren't flattened, and are kept as the
+	// values of the flattened keys.
+	KeepArrays ArrayNotation = iota
+
+	// SeparatorIndexes defines that arrays are flattened, with the indexes of
+	// their elements separated like keys, such as "a.0.b".
+	SeparatorIndexes
+
+	// BracketIndexes defines that arrays are flattened, with the indexes of
+	// their elements enclosed in brackets, such as "a[0].b".
+	BracketIndexes
+)
+
+// FlattenArrays takes an ArrayNotation and returns a FlattenOption that
+// flattens arrays with the given notation. By default, arrays are kept.
+func FlattenArrays(notation ArrayNotation) FlattenOption {
+	return func(f *flattener) {
+		f.notation = notation
+	}
+}
+
+// FlattenDepth takes a maximum depth and returns a FlattenOption that only
+// flattens up to the given number of levels of nesting, keeping any deeper
+// values as the values of the flattened keys. By default, or with a maximum
+// depth of zero, all levels are flattened.
+func FlattenDepth(maxDepth int) FlattenOption {
+	return func(f *flattener) {
+		f.maxDepth = maxDepth
+	}
+}
+
+// Flatten takes a separator and a variable number of `FlattenOption`s and
+// returns a Transformer that converts nested JSON objects to and from a single
+// "flat" JSON object, whose keys are the paths of the nested values, joined
+// with the separator.
+//
+// For the "Marshal" direction, nested objects are flattened, so that
+// `{"a":{"b":1}}` becomes `{"a.b":1}` with a "." separator. For the
+// "Unmarshal" direction, flattened keys are expanded, so that `{"a.b":1}`
+// becomes `{"a":{"b":1}}`.
+//
+// Empty objects and arrays are kept as the values of the flattened keys, as
+// they have no values to flatten. Only data that is a JSON object is
+// transformed, and keys that contain the separator can't be expanded back to
+// their original form. Flattened data with a key that's both the key of a
+// value and the prefix of another key, such as `{"a":1,"a.b":2}`, can't be
+// expanded without losing one of the values, so it's returned as-is.
+func Flatten(separator string, options ...FlattenOption) Transformer {
+	f := &flattener{separator: separator}
+
+	for _, option := range options {
+		option(f)
+	}
+
+	return func(data []byte, direction Direction) []byte {
+		root, err := jsontree.Parse(data)
+
+		if nil != err || jsontree.Object != root.Kind {
+			return data
+		}
+
+		if Unmarshal == direction {
+			return f.expand(root, data)
+		}
+
+		return append(f.flatten([]byte{'{'}, "", 0, root, data), '}')
+	}
+}
+
+// flatten takes a buffer, the flattened key of a value, the depth of the
+// value, the value's node, and the data that the node was parsed from, and
+// returns the buffer with the flattened members of the value appended to it.
+func (f *flattener) flatten(buffer []byte, key string, depth int, node *jsontree.Node, data []byte) []byte {
+	isFlattened := 0 == depth ||
+		((0 == f.maxDepth || depth <= f.maxDepth) &&
+			((jsontree.Object == node.Kind && 0 < len(node.Members)) ||
+				(jsontree.Array == node.Kind && 0 < len(node.Elements) && KeepArrays != f.notation)))
+
+	if !isFlattened {
+		if '{' != buffer[len(buffer)-1] {
+			buffer = append(buffer, ',')
+		}
+
+		buffer = appendCanonicalString(buffer, key)
+		buffer = append(buffer, ':')
+
+		return append(buffer, node.Raw(data)...)
+	}
+
+	if jsontree.Array == node.Kind {
+		for i, element := range node.Elements {
+			elementKey := key + f.separator + strconv.Itoa(i)
+
+			if BracketIndexes == f.notation {
+				elementKey = key + "[" + strconv.Itoa(i) + "]"
+			}
+
+			buffer = f.flatten(buffer, elementKey, depth+1, element, data)
+		}
+
+		return buffer
+	}
+
+	for _, member := range node.Members {
+		memberKey := member.Key
+
+		if 0 < depth {
+			memberKey = key + f.separator + member.Key
+		}
+
+		buffer = f.flatten(buffer, memberKey, depth+1, member.Value, data)
+	}
+
+	return buffer
+}
+
+// expand takes the node of a flattened JSON object and the data that the node
+// was parsed from, and returns the expanded form of the object, or the data
+// as-is if a key is both a value's key and the prefix of another key.
+func (f *flattener) expand(node *jsontree.Node, data []byte) []byte {
+	root := &flatTree{}
+
+	for _, member := range node.Members {
+		segments := f.segments(member.Key, len(node.Members))
+
+		// Keys deeper than the maximum depth weren't flattened
+		if 0 < f.maxDepth && len(segments) > f.maxDepth+1 {
+			last := segments[f.maxDepth]
+			segments = append(segments[:f.maxDepth], flatSegment{key: member.Key[last.start:]})
+		}
+
+		tree := root
+
+		for _, segment := range segments {
+			// A key can't be both a value and the prefix of other keys
+			if nil != tree.raw {
+				return data
+			}
+
+			tree = tree.child(segment)
+		}
+
+		if nil != tree.members || nil != tree.elements {
+			return data
+		}
+
+		*tree = flatTree{raw: member.Value.Raw(data)}
+	}
+
+	return root.appendTo(nil)
+}
+
+// segments takes a flattened key and the number of flattened keys, and returns
+// the segments of the key. As each flattened array element has at least one
+// key, indexes beyond the number of keys are kept as object keys.
+func (f *flattener) segments(key string, limit int) []flatSegment {
+	var segments []flatSegment
+
+	for start := 0; ; {
+		end := len(key)
+
+		if i := strings.Index(key[start:], f.separator); "" != f.separator && -1 != i {
+			end = start + i
+		}
+
+		part := key[start:end]
+		partStart := start
+
+		// Split off any bracketed indexes at the end of the part
+		var indexes []flatSegment
+
+		for BracketIndexes == f.notation && strings.HasSuffix(part, "]") {
+			open := strings.LastIndex(part, "[")
+			index, err := strconv.Atoi(part[open+1 : len(part)-1])
+
+			if -1 == open || nil != err || 0 > index || index >= limit {
+				break
+			}
+
+			indexes = append([]flatSegment{{index: index, isIndex: true, start: partStart + open}}, indexes...)
+			part = part[:open]
+		}
+
+		if index, err := strconv.Atoi(part); SeparatorIndexes == f.notation && nil == err && 0 <= index && index < limit {
+			segments = append(segments, flatSegment{index: index, isIndex: true, start: partStart})
+		} else if "" != part || 0 == len(indexes) {
+			segments = append(segments, flatSegment{key: part, start: partStart})
+		}
+
+		segments = append(segments, indexes...)
+
+		if end == len(key) {
+			return segments
+		}
+
+		start = end + len(f.separator)
+	}
+}
+
+// child takes a segment and returns the tree's child at the segment, adding
+// the child if it doesn't exist yet.
+func (t *flatTree) child(segment flatSegment) *flatTree {
+	// Mixed segments make an object, with indexes as keys
+	if !segment.isIndex && nil != t.elements {
+		for _, index := range t.indexes() {
+			t.addMember(strconv.Itoa(index), t.elements[index])
+		}
+
+		t.elements = nil
+	}
+
+	if segment.isIndex && nil == t.members {
+		if nil == t.elements {
+			t.elements = make(map[int]*flatTree)
+		}
+
+		if _, exists := t.elements[segment.index]; !exists {
+			t.elements[segment.index] = &flatTree{}
+		}
+
+		return t.elements[segment.index]
+	}
+
+	key := segment.key
+
+	if segment.isIndex {
+		key = strconv.Itoa(segment.index)
+	}
+
+	if _, exists := t.members[key]; !exists {
+		t.addMember(key, &flatTree{})
+	}
+
+	return t.members[key]
+}
+
+// addMember takes a key and a tree and adds the tree as a member of the tree.
+func (t *flatTree) addMember(key string, member *flatTree) {
+	if nil == t.members {
+		t.members = make(map[string]*flatTree)
+	}
+
+	if _, exists := t.members[key]; !exists {
+		t.keys = append(t.keys, key)
+	}
+
+	t.members[key] = member
+}
+
+// indexes returns the sorted indexes of the tree's elements.
+func (t *flatTree) indexes() []int {
+	indexes := make([]int, 0, len(t.elements))
+
+	for index := range t.elements {
+		indexes = append(indexes, index)
+	}
+
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+// appendTo takes a buffer and returns the buffer with the tree's JSON value
+// appended to it. Missing array elements are null.
+func (t *flatTree) appendTo(buffer []byte) []byte {
+	switch {
+	case nil != t.members:
+		buffer = append(buffer, '{')
+
+		for i, key := range t.keys {
+			if 0 < i {
+				buffer = append(buffer, ',')
+			}
+
+			buffer = appendCanonicalString(buffer, key)
+			buffer = append(buffer, ':')
+			buffer = t.members[key].appendTo(buffer)
+		}
+
+		return append(buffer, '}')
+	case nil != t.elements:
+		indexes := t.indexes()
+
+		buffer = append(buffer, '[')
+
+		for i := 0; i <= indexes[len(indexes)-1]; i++ {
+			if 0 < i {
+				buffer = append(buffer, ',')
+			}
+
+			if element, exists := t.elements[i]; exists {
+				buffer = element.appendTo(buffer)
+			} else {
+				buffer = append(buffer, "null"...)
+			}
+		}
+
+		return append(buffer, ']')
+	case nil != t.raw:
+		return append(buffer, t.raw...)
+	}
+
+	return append(buffer, "{}"...)
+}
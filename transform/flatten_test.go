@@ -0,0 +1,77 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	for _, testCase := range []struct {
+		options       []FlattenOption
+		expandedJSON  string
+		flattenedJSON string
+	}{
+		{nil, `{"a":{"b":1,"c":{"d":"x"}},"e":true}`, `{"a.b":1,"a.c.d":"x","e":true}`},
+		{nil, `{"a":{},"b":[],"c":[1,{"d":2}]}`, `{"a":{},"b":[],"c":[1,{"d":2}]}`},
+		{[]FlattenOption{FlattenArrays(SeparatorIndexes)}, `{"a":[1,{"b":[2,3]}],"c":[]}`, `{"a.0":1,"a.1.b.0":2,"a.1.b.1":3,"c":[]}`},
+		{[]FlattenOption{FlattenArrays(BracketIndexes)}, `{"a":[1,{"b":[[2],3]}]}`, `{"a[0]":1,"a[1].b[0][0]":2,"a[1].b[1]":3}`},
+		{[]FlattenOption{FlattenDepth(1)}, `{"a":{"b":{"c":1}},"d":{"e":2}}`, `{"a.b":{"c":1},"d.e":2}`},
+		{[]FlattenOption{FlattenDepth(2), FlattenArrays(SeparatorIndexes)}, `{"a":[{"b":{"c":1}}]}`, `{"a.0.b":{"c":1}}`},
+	} {
+		trans := Flatten(".", testCase.options...)
+
+		if output := trans([]byte(testCase.expandedJSON), Marshal); testCase.flattenedJSON != string(output) {
+			t.Errorf("Marshal output of %s doesn't match expected %s", output, testCase.flattenedJSON)
+		}
+
+		if output := trans([]byte(testCase.flattenedJSON), Unmarshal); testCase.expandedJSON != string(output) {
+			t.Errorf("Unmarshal output of %s doesn't match expected %s", output, testCase.expandedJSON)
+		}
+	}
+}
+
+func TestFlattenExpandsUnordered(t *testing.T) {
+	for _, testCase := range []struct {
+		options        []FlattenOption
+		inputJSON      string
+		expectedOutput string
+	}{
+		{nil, `{"a.b":1,"c":2,"a.d":3}`, `{"a":{"b":1,"d":3},"c":2}`},
+		{[]FlattenOption{FlattenArrays(SeparatorIndexes)}, `{"a.2":3,"a.0":1,"b":0}`, `{"a":[1,null,3],"b":0}`},
+		{[]FlattenOption{FlattenArrays(SeparatorIndexes)}, `{"a.0":1,"a.b":2}`, `{"a":{"0":1,"b":2}}`},
+		{[]FlattenOption{FlattenArrays(SeparatorIndexes)}, `{"a.9999":1}`, `{"a":{"9999":1}}`},
+	} {
+		if output := Flatten(".", testCase.options...)([]byte(testCase.inputJSON), Unmarshal); testCase.expectedOutput != string(output) {
+			t.Errorf("Output of %s doesn't match expected %s", output, testCase.expectedOutput)
+		}
+	}
+}
+
+func TestFlattenLeavesConflictingKeys(t *testing.T) {
+	for _, testCase := range []struct {
+		options   []FlattenOption
+		inputJSON string
+	}{
+		{nil, `{"a":1,"a.b":2}`},
+		{nil, `{"a.b":2,"a":1}`},
+		{nil, `{"a.b.c":1,"x":0,"a.b":2}`},
+		{nil, `{"a":{},"a.b":2}`},
+		{[]FlattenOption{FlattenArrays(SeparatorIndexes)}, `{"a.0":1,"a":[]}`},
+		{[]FlattenOption{FlattenArrays(BracketIndexes)}, `{"a[0]":1,"a[0].b":2}`},
+	} {
+		if output := Flatten(".", testCase.options...)([]byte(testCase.inputJSON), Unmarshal); testCase.inputJSON != string(output) {
+			t.Errorf("Output of %s doesn't match expected %s", output, testCase.inputJSON)
+		}
+	}
+}
+
+func TestFlattenNonObject(t *testing.T) {
+	trans := Flatten(".")
+
+	for _, inputJSON := range []string{`[{"a":{"b":1}}]`, `"a.b"`, `{"a":`} {
+		for _, direction := range []Direction{Marshal, Unmarshal} {
+			if output := trans([]byte(inputJSON), direction); inputJSON != string(output) {
+				t.Errorf("%s output of %s doesn't match expected %s", direction, output, inputJSON)
+			}
+		}
+	}
+}
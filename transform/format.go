@@ -0,0 +1,53 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Indent takes a prefix and an indent and returns a Transformer that formats
+// the transformed data with each JSON element on a new line, beginning with the
+// prefix followed by one or more copies of the indent, according to the
+// element's nesting depth, for either direction.
+//
+// Data that isn't valid JSON is returned as-is. The conjson marshaling
+// functions, such as `conjson.Marshal` and `Codec.Marshal`, return the indented
+// output as-is, and the encoders that write to an `io.Writer`, such as those
+// returned by `conjson.NewStreamEncoder` and `Codec.NewStreamEncoder`, write it
+// as-is. However, `encoding/json` compacts the output of an
+// `encoding/json.Marshaler`, so the indentation is lost when marshaling with
+// `conjson.NewMarshaler` or encoding with an encoder that wraps an
+// `encoding/json.Encoder`, such as one returned by `conjson.NewEncoder`, whose
+// own SetIndent method formats the output instead.
+//
+// See the documentation for `encoding/json.Indent` for more details.
+func Indent(prefix, indent string) Transformer {
+	return func(data []byte, direction Direction) []byte {
+		var indented bytes.Buffer
+
+		if err := json.Indent(&indented, data, prefix, indent); nil != err {
+			return data
+		}
+
+		return indented.Bytes()
+	}
+}
+
+// Compact returns a Transformer that removes the insignificant whitespace from
+// the transformed data, for either direction, such as to normalize the
+// formatting of the data before it's hashed.
+//
+// Data that isn't valid JSON is returned as-is.
+//
+// See the documentation for `encoding/json.Compact` for more details.
+func Compact() Transformer {
+	return func(data []byte, direction Direction) []byte {
+		var compacted bytes.Buffer
+
+		if err := json.Compact(&compacted, data); nil != err {
+			return data
+		}
+
+		return compacted.Bytes()
+	}
+}
@@ -0,0 +1,44 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestIndent(t *testing.T) {
+	const inputJSON = `{"a":[1,2],"b":{}}`
+	const expectedJSON = "{\n>\t\"a\": [\n>\t\t1,\n>\t\t2\n>\t],\n>\t\"b\": {}\n>}"
+
+	trans := Indent(">", "\t")
+
+	for _, direction := range []Direction{Marshal, Unmarshal} {
+		if output := trans([]byte(inputJSON), direction); expectedJSON != string(output) {
+			t.Errorf("%s output of %s doesn't match expected %s", direction, output, expectedJSON)
+		}
+	}
+
+	if output := trans([]byte(`{"a":`), Marshal); `{"a":` != string(output) {
+		t.Errorf("Output of %s doesn't match expected %s", output, `{"a":`)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	const inputJSON = "{\n  \"a\": [1, 2],\n  \"b\": \"c d\"\n}\n"
+	const expectedJSON = `{"a":[1,2],"b":"c d"}`
+
+	trans := Compact()
+
+	for _, direction := range []Direction{Marshal, Unmarshal} {
+		if output := trans([]byte(inputJSON), direction); expectedJSON != string(output) {
+			t.Errorf("%s output of %s doesn't match expected %s", direction, output, expectedJSON)
+		}
+	}
+
+	// Compacting normalizes formatting, such as before hashing
+	if output := Bytes([]byte(inputJSON), Marshal, Compact(), Indent("", "  "), Compact()); expectedJSON != string(output) {
+		t.Errorf("Output of %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	if output := trans([]byte(`{"a": `), Marshal); `{"a": ` != string(output) {
+		t.Errorf("Output of %s doesn't match expected %s", output, `{"a": `)
+	}
+}
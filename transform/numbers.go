@@ -0,0 +1,108 @@
+package transform
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// maxSafeInteger is the decimal form of 2^53, the largest integer beyond which
+// not every integer is exactly representable as an IEEE 754 double, such as
+// the numbers of JavaScript.
+const maxSafeInteger = "9007199254740992"
+
+// NumberStrings takes a Selector and returns a Transformer that converts the
+// selected JSON values between numbers and strings, so that large integers
+// survive JSON consumers that decode every number as a float64.
+//
+// For the "Marshal" direction, selected integers greater than 2^53 in
+// magnitude are converted to strings, so that `{"id":9007199254740993}`
+// becomes `{"id":"9007199254740993"}`. For the "Unmarshal" direction, selected
+// strings that contain a JSON number are converted to numbers, so that
+// `{"id":"42"}` becomes `{"id":42}`.
+//
+// The digits of the numbers are kept exactly, without being parsed. When a
+// selected value is an array, its elements are converted instead.
+func NumberStrings(selector Selector) Transformer {
+	return func(data []byte, direction Direction) []byte {
+		convert := quoteLargeInteger
+
+		if Unmarshal == direction {
+			convert = unquoteNumber
+		}
+
+		return rewriteValues(data, func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			if !selector(valuePath, isMember) {
+				return nil, false
+			}
+
+			return rewriteElements(node, value, convert), true
+		}, nil)
+	}
+}
+
+// rewriteElements takes the node of a JSON value, the value's bytes, and a
+// function that converts a scalar value, and returns the converted value, or
+// the value with its elements converted, recursively, if it's an array.
+// Objects are kept as-is.
+func rewriteElements(node *jsontree.Node, value []byte, convert func(*jsontree.Node, []byte) []byte) []byte {
+	switch node.Kind {
+	case jsontree.Object:
+		return value
+	case jsontree.Array:
+		return rewriteValues(value, func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			switch {
+			case jsontree.Object == node.Kind:
+				return value, true
+			case jsontree.Array != node.Kind:
+				return convert(node, value), true
+			}
+
+			return nil, false
+		}, nil)
+	}
+
+	return convert(node, value)
+}
+
+// quoteLargeInteger takes the node of a JSON value and the value's bytes and
+// returns the value as a string, if it's an integer greater than 2^53 in
+// magnitude, or otherwise as-is.
+func quoteLargeInteger(node *jsontree.Node, value []byte) []byte {
+	if jsontree.Number != node.Kind || -1 != strings.IndexAny(string(value), ".eE") {
+		return value
+	}
+
+	digits := strings.TrimPrefix(string(value), "-")
+
+	if len(digits) < len(maxSafeInteger) || (len(digits) == len(maxSafeInteger) && digits <= maxSafeInteger) {
+		return value
+	}
+
+	return append(append([]byte{'"'}, value...), '"')
+}
+
+// unquoteNumber takes the node of a JSON value and the value's bytes and
+// returns the number contained in the value, if it's a string containing
+// exactly a JSON number, or otherwise the value as-is.
+func unquoteNumber(node *jsontree.Node, value []byte) []byte {
+	var content string
+
+	if jsontree.String != node.Kind || nil != json.Unmarshal(value, &content) {
+		return value
+	}
+
+	if !isNumber(content) {
+		return value
+	}
+
+	return []byte(content)
+}
+
+// isNumber takes a string and returns whether it's exactly a JSON number.
+func isNumber(s string) bool {
+	number, err := jsontree.Parse([]byte(s))
+
+	return nil == err && jsontree.Number == number.Kind && len(s) == number.End-number.Start
+}
@@ -0,0 +1,45 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestNumberStrings(t *testing.T) {
+	trans := NumberStrings(Keys("*_id", "ids"))
+
+	for _, testCase := range []struct {
+		direction      Direction
+		inputJSON      string
+		expectedOutput string
+	}{
+		{Marshal, `{"user_id": 9007199254740993, "count": 9007199254740993}`, `{"user_id": "9007199254740993", "count": 9007199254740993}`},
+		{Marshal, `{"user_id": -123456789012345678901234567890}`, `{"user_id": "-123456789012345678901234567890"}`},
+		{Marshal, `{"user_id": 9007199254740992, "team_id": -9007199254740992}`, `{"user_id": 9007199254740992, "team_id": -9007199254740992}`},
+		{Marshal, `{"user_id": 9007199254740993.5, "team_id": 1e300}`, `{"user_id": 9007199254740993.5, "team_id": 1e300}`},
+		{Marshal, `{"ids": [1, 18446744073709551615, [18446744073709551616]]}`, `{"ids": [1, "18446744073709551615", ["18446744073709551616"]]}`},
+		{Marshal, `{"ids": {"a": 18446744073709551615}}`, `{"ids": {"a": 18446744073709551615}}`},
+		{Unmarshal, `{"user_id": "18446744073709551615", "name": "42"}`, `{"user_id": 18446744073709551615, "name": "42"}`},
+		{Unmarshal, `{"user_id": "-1.5e3", "team_id": "42"}`, `{"user_id": -1.5e3, "team_id": 42}`},
+		{Unmarshal, `{"user_id": "abc", "team_id": " 42", "org_id": "042", "app_id": ""}`, `{"user_id": "abc", "team_id": " 42", "org_id": "042", "app_id": ""}`},
+		{Unmarshal, `{"ids": ["1", 2, "x"]}`, `{"ids": [1, 2, "x"]}`},
+	} {
+		if output := trans([]byte(testCase.inputJSON), testCase.direction); testCase.expectedOutput != string(output) {
+			t.Errorf("%s output of %s doesn't match expected %s", testCase.direction, output, testCase.expectedOutput)
+		}
+	}
+}
+
+func TestNumberStringsPaths(t *testing.T) {
+	const inputJSON = `{"id":18446744073709551615,"items":[{"id":18446744073709551615}]}`
+	const expectedJSON = `{"id":18446744073709551615,"items":[{"id":"18446744073709551615"}]}`
+
+	trans := NumberStrings(Paths("items.*.id"))
+
+	if output := trans([]byte(inputJSON), Marshal); expectedJSON != string(output) {
+		t.Errorf("Marshal output of %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	if output := trans([]byte(expectedJSON), Unmarshal); inputJSON != string(output) {
+		t.Errorf("Unmarshal output of %s doesn't match expected %s", output, inputJSON)
+	}
+}
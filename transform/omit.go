@@ -0,0 +1,81 @@
+package transform
+
+import (
+	"bytes"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// Empty flags the kinds of "empty" JSON values that are omitted by OmitEmpty.
+type Empty uint8
+
+const (
+	// Nulls flags JSON null literals.
+	Nulls Empty = 1 << iota
+
+	// EmptyStrings flags empty JSON strings.
+	EmptyStrings
+
+	// EmptyArrays flags JSON arrays without any elements.
+	EmptyArrays
+
+	// EmptyObjects flags JSON objects without any members.
+	EmptyObjects
+
+	// AllEmpty flags every kind of empty JSON value.
+	AllEmpty = Nulls | EmptyStrings | EmptyArrays | EmptyObjects
+)
+
+// DropNulls returns a Transformer that removes every JSON object member whose
+// value is null, at any depth of the transformed data.
+//
+// See the documentation for OmitEmpty for more details.
+func DropNulls() Transformer {
+	return OmitEmpty(Nulls)
+}
+
+// OmitEmpty takes a set of Empty flags and returns a Transformer that removes
+// every JSON object member whose value is of any of the flagged kinds of empty
+// values, at any depth of the transformed data, much like the "omitempty"
+// option of `encoding/json` struct tags, but without requiring tags.
+//
+// Members are removed recursively, so an object or array that's left empty by
+// the removal of its members is itself removed, if empty objects or arrays are
+// flagged. Array elements are never removed, so that the positions of the
+// remaining elements are kept.
+//
+// The Transformer runs in both directions. To only run it in one direction,
+// wrap it with OnlyForDirection.
+func OmitEmpty(empty Empty) Transformer {
+	return func(data []byte, direction Direction) []byte {
+		return rewriteValues(data, nil, func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			return nil, isMember && empty.matches(node.Kind, value)
+		})
+	}
+}
+
+// matches takes the kind of a JSON value and the value's bytes and returns
+// whether the value is of any of the flagged kinds of empty values.
+func (e Empty) matches(kind jsontree.Kind, value []byte) bool {
+	compact := bytes.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r':
+			return -1
+		}
+
+		return r
+	}, value)
+
+	switch kind {
+	case jsontree.Null:
+		return 0 != e&Nulls
+	case jsontree.String:
+		return 0 != e&EmptyStrings && `""` == string(value)
+	case jsontree.Array:
+		return 0 != e&EmptyArrays && "[]" == string(compact)
+	case jsontree.Object:
+		return 0 != e&EmptyObjects && "{}" == string(compact)
+	}
+
+	return false
+}
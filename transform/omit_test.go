@@ -0,0 +1,45 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestDropNulls(t *testing.T) {
+	const inputJSON = `{"a": null, "b": "", "c": [null, {"d": null}], "e": {"f": null}}`
+	const expectedJSON = `{"b": "", "c": [null, {}], "e": {}}`
+
+	trans := DropNulls()
+
+	for _, direction := range []Direction{Marshal, Unmarshal} {
+		if output := trans([]byte(inputJSON), direction); expectedJSON != string(output) {
+			t.Errorf("%s output of %s doesn't match expected %s", direction, output, expectedJSON)
+		}
+	}
+}
+
+func TestOmitEmpty(t *testing.T) {
+	const inputJSON = `{"a": null, "b": "", "c": [], "d": { }, "e": {"f": {"g": null}}, "h": [{}, ""], "i": 0, "j": false}`
+
+	for _, testCase := range []struct {
+		empty          Empty
+		expectedOutput string
+	}{
+		{Nulls, `{"b": "", "c": [], "d": { }, "e": {"f": {}}, "h": [{}, ""], "i": 0, "j": false}`},
+		{EmptyStrings, `{"a": null, "c": [], "d": { }, "e": {"f": {"g": null}}, "h": [{}, ""], "i": 0, "j": false}`},
+		{EmptyArrays, `{"a": null, "b": "", "d": { }, "e": {"f": {"g": null}}, "h": [{}, ""], "i": 0, "j": false}`},
+		{EmptyObjects, `{"a": null, "b": "", "c": [], "e": {"f": {"g": null}}, "h": [{}, ""], "i": 0, "j": false}`},
+		{Nulls | EmptyObjects, `{"b": "", "c": [], "h": [{}, ""], "i": 0, "j": false}`},
+		{AllEmpty, `{"h": [{}, ""], "i": 0, "j": false}`},
+	} {
+		if output := OmitEmpty(testCase.empty)([]byte(inputJSON), Marshal); testCase.expectedOutput != string(output) {
+			t.Errorf("Output of %s doesn't match expected %s", output, testCase.expectedOutput)
+		}
+	}
+
+	// Running in only one direction is done by composition
+	trans := OnlyForDirection(Marshal, OmitEmpty(AllEmpty))
+
+	if output := trans([]byte(inputJSON), Unmarshal); inputJSON != string(output) {
+		t.Errorf("Unmarshal output of %s doesn't match expected %s", output, inputJSON)
+	}
+}
@@ -0,0 +1,88 @@
+package transform
+
+import (
+	"path"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// Project takes a list of paths and returns a Transformer that keeps only the
+// JSON values at the given paths, along with the objects and arrays that
+// enclose them, removing every other value from the transformed data.
+//
+// Paths are the keys of objects and the indexes of arrays, separated by dots,
+// such as "owner.email". A "*" segment matches any key or index, so that
+// "items.*.id" keeps the "id" of every element of "items", and segments may
+// otherwise contain the wildcards of `path.Match`.
+//
+// Paths are written in the convention of the JSON data, but keys are matched
+// regardless of their case convention, so that a Project transformer composes
+// with ConventionalKeys in either order. Scalar values found where an enclosing
+// object or array is expected are kept as-is.
+func Project(paths ...string) Transformer {
+	patterns := splitPaths(paths)
+
+	return func(data []byte, direction Direction) []byte {
+		return rewriteValues(data, func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			if 0 == len(valuePath) {
+				return nil, false
+			}
+
+			isEnclosing := false
+
+			for _, segments := range patterns {
+				if !matchPrefix(segments, valuePath) {
+					continue
+				}
+
+				if len(valuePath) == len(segments) {
+					return value, true
+				}
+
+				isEnclosing = true
+			}
+
+			switch {
+			case !isEnclosing:
+				return nil, true
+			case jsontree.Object != node.Kind && jsontree.Array != node.Kind:
+				return value, true
+			}
+
+			return nil, false
+		}, nil)
+	}
+}
+
+// Exclude takes a list of paths and returns a Transformer that removes the
+// JSON values at the given paths from the transformed data.
+//
+// Paths are written and matched the same as they are for Project.
+func Exclude(paths ...string) Transformer {
+	patterns := splitPaths(paths)
+
+	return func(data []byte, direction Direction) []byte {
+		return rewriteValues(data, func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			for _, segments := range patterns {
+				if len(valuePath) == len(segments) && matchPrefix(segments, valuePath) {
+					return nil, true
+				}
+			}
+
+			return nil, false
+		}, nil)
+	}
+}
+
+// matchPrefix takes the segments of a path pattern and the path of a JSON value
+// and returns whether the path matches the pattern, up to the length of the
+// shorter of the two, regardless of the case conventions of their keys.
+func matchPrefix(segments []string, valuePath []string) bool {
+	for i := 0; i < len(segments) && i < len(valuePath); i++ {
+		if matched, _ := path.Match(normalizeKey(segments[i]), normalizeKey(valuePath[i])); !matched {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	const inputJSON = `{"id": 1, "name": "a", "secret": "s", "owner": {"email": "e", "phone": "p"}, "items": [{"id": 2, "price": 3}, {"id": 4}], "tags": null}`
+
+	for _, testCase := range []struct {
+		paths          []string
+		expectedOutput string
+	}{
+		{[]string{"id", "name", "owner.email"}, `{"id": 1, "name": "a", "owner": {"email": "e"}}`},
+		{[]string{"owner"}, `{"owner": {"email": "e", "phone": "p"}}`},
+		{[]string{"items.*.id"}, `{"items": [{"id": 2}, {"id": 4}]}`},
+		{[]string{"items.0"}, `{"items": [{"id": 2, "price": 3}]}`},
+		{[]string{"tags.*.name", "missing"}, `{"tags": null}`},
+		{[]string{"*"}, inputJSON},
+		{nil, `{}`},
+	} {
+		if output := Project(testCase.paths...)([]byte(inputJSON), Marshal); testCase.expectedOutput != string(output) {
+			t.Errorf("Output of %s doesn't match expected %s", output, testCase.expectedOutput)
+		}
+	}
+}
+
+func TestExclude(t *testing.T) {
+	const inputJSON = `{"id": 1, "secret": "s", "owner": {"email": "e", "phone": "p"}, "items": [{"id": 2, "price": 3}, {"id": 4}]}`
+
+	for _, testCase := range []struct {
+		paths          []string
+		expectedOutput string
+	}{
+		{[]string{"secret", "owner.phone"}, `{"id": 1, "owner": {"email": "e"}, "items": [{"id": 2, "price": 3}, {"id": 4}]}`},
+		{[]string{"items.*.price", "owner"}, `{"id": 1, "secret": "s", "items": [{"id": 2}, {"id": 4}]}`},
+		{[]string{"items.1", "missing"}, `{"id": 1, "secret": "s", "owner": {"email": "e", "phone": "p"}, "items": [{"id": 2, "price": 3}]}`},
+		{nil, inputJSON},
+	} {
+		if output := Exclude(testCase.paths...)([]byte(inputJSON), Marshal); testCase.expectedOutput != string(output) {
+			t.Errorf("Output of %s doesn't match expected %s", output, testCase.expectedOutput)
+		}
+	}
+}
+
+func TestProjectWithConventionalKeys(t *testing.T) {
+	const inputJSON = `{"userId":1,"displayName":"a","billingAddress":{"postalCode":"p","street":"s"}}`
+	const expectedJSON = `{"user_id":1,"billing_address":{"postal_code":"p"}}`
+
+	project := Project("user_id", "billing_address.postal_code")
+
+	for _, transformers := range [][]Transformer{
+		{ConventionalKeys(), project},
+		{project, ConventionalKeys()},
+	} {
+		if output := Bytes([]byte(inputJSON), Marshal, transformers...); expectedJSON != string(output) {
+			t.Errorf("Marshal output of %s doesn't match expected %s", output, expectedJSON)
+		}
+	}
+
+	if output := Bytes([]byte(`{"user_id":1,"display_name":"a"}`), Unmarshal, ConventionalKeys(), project); `{"userId":1}` != string(output) {
+		t.Errorf("Unmarshal output of %s doesn't match expected %s", output, `{"userId":1}`)
+	}
+}
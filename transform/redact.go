@@ -0,0 +1,44 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// Redact takes a list of key patterns and a replacement and returns a
+// Transformer that, for the "Marshal" direction only, replaces the value of
+// every JSON object member whose key matches any of the patterns with the
+// given replacement, at any depth of the transformed data.
+//
+// The replacement must be a valid JSON value, such as `[]byte(`"[REDACTED]"`)`,
+// and Redact panics if it isn't, rather than returning a Transformer that
+// would emit invalid JSON. If the replacement is nil, the matching members are
+// removed entirely.
+//
+// When run by the conjson package, the Transformer sees the entire document,
+// including the JSON of nested values with their own convention, of Value
+// wrappers, and of Extras fields, so that their secrets are redacted too. Only
+// the JSON of values that a Codec treats as opaque, with OpaqueMarshalers, is
+// kept out of its sight.
+//
+// Keys are matched regardless of their case convention, so that the pattern
+// "api_token" matches the keys "api_token", "apiToken", "ApiToken", and
+// "APIToken". Patterns may contain the wildcards of `path.Match`, such as
+// "*_token".
+func Redact(patterns []string, replacement []byte) Transformer {
+	if nil != replacement && !json.Valid(replacement) {
+		panic(fmt.Sprintf("transform: Redact replacement %q isn't a valid JSON value", replacement))
+	}
+
+	return OnlyForDirection(Marshal, func(data []byte, direction Direction) []byte {
+		return rewriteValues(data, func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			if !isMember || !matchKey(patterns, valuePath[len(valuePath)-1]) {
+				return nil, false
+			}
+
+			return replacement, true
+		}, nil)
+	})
+}
@@ -0,0 +1,47 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	const inputJSON = `{"user":"a","password":"secret","apiToken":"b","APIToken":"d","nested":[{"refresh_token":"c","ssn":{"value":1}}]}`
+
+	for _, testCase := range []struct {
+		replacement    []byte
+		direction      Direction
+		expectedOutput string
+	}{
+		{
+			[]byte(`"[REDACTED]"`),
+			Marshal,
+			`{"user":"a","password":"[REDACTED]","apiToken":"[REDACTED]","APIToken":"[REDACTED]","nested":[{"refresh_token":"[REDACTED]","ssn":"[REDACTED]"}]}`,
+		},
+		{
+			nil,
+			Marshal,
+			`{"user":"a","nested":[{}]}`,
+		},
+		{
+			[]byte(`"[REDACTED]"`),
+			Unmarshal,
+			inputJSON,
+		},
+	} {
+		trans := Redact([]string{"password", "*_token", "ssn"}, testCase.replacement)
+
+		if output := trans([]byte(inputJSON), testCase.direction); testCase.expectedOutput != string(output) {
+			t.Errorf("%s output of %s doesn't match expected %s", testCase.direction, output, testCase.expectedOutput)
+		}
+	}
+}
+
+func TestRedact_AssertInvalidReplacementIsRejected(t *testing.T) {
+	defer func() {
+		if recovered := recover(); nil == recovered {
+			t.Error("Redact didn't panic with an invalid replacement")
+		}
+	}()
+
+	Redact([]string{"password"}, []byte(`[REDACTED]`))
+}
@@ -0,0 +1,73 @@
+package transform
+
+import (
+	"path"
+	"strings"
+)
+
+// Selector defines a function that takes the path of a JSON value, by the keys
+// of objects and the indexes of arrays, and whether the value is the value of
+// an object member, and returns whether the value is selected.
+type Selector func(valuePath []string, isMember bool) bool
+
+// Keys takes a list of key patterns and returns a Selector that selects the
+// value of every JSON object member whose key matches any of the patterns, at
+// any depth of the data.
+//
+// Keys are matched regardless of their case convention, so that the pattern
+// "user_id" matches the keys "user_id", "userId", and "UserId". Patterns may
+// contain the wildcards of `path.Match`, such as "*_id".
+func Keys(patterns ...string) Selector {
+	return func(valuePath []string, isMember bool) bool {
+		return isMember && matchKey(patterns, valuePath[len(valuePath)-1])
+	}
+}
+
+// Paths takes a list of paths and returns a Selector that selects the value at
+// any of the paths, from the top-level value of the data.
+//
+// Paths are the keys of objects and the indexes of arrays, separated by dots,
+// such as "users.0.id". A "*" segment matches any key or index, and segments
+// may otherwise contain the wildcards of `path.Match`. Keys are matched exactly
+// as they appear in the transformed data.
+func Paths(paths ...string) Selector {
+	split := splitPaths(paths)
+
+	return func(valuePath []string, isMember bool) bool {
+		for _, segments := range split {
+			if matchPath(segments, valuePath) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// splitPaths takes a list of dot-separated paths and returns the segments of
+// each path.
+func splitPaths(paths []string) [][]string {
+	split := make([][]string, len(paths))
+
+	for i, p := range paths {
+		split[i] = strings.Split(p, ".")
+	}
+
+	return split
+}
+
+// matchPath takes the segments of a path pattern and the path of a JSON value
+// and returns whether the path matches the pattern.
+func matchPath(segments []string, valuePath []string) bool {
+	if len(segments) != len(valuePath) {
+		return false
+	}
+
+	for i, segment := range segments {
+		if matched, _ := path.Match(segment, valuePath[i]); !matched {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,47 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	selector := Keys("user_id", "*_token")
+
+	for _, testCase := range []struct {
+		valuePath []string
+		isMember  bool
+		expected  bool
+	}{
+		{[]string{"userId"}, true, true},
+		{[]string{"a", "0", "UserId"}, true, true},
+		{[]string{"accessToken"}, true, true},
+		{[]string{"user_ids"}, true, false},
+		{[]string{"user_id", "0"}, false, false},
+	} {
+		if selected := selector(testCase.valuePath, testCase.isMember); testCase.expected != selected {
+			t.Errorf("Selection of %v was `%t`, when expected to be `%t`", testCase.valuePath, selected, testCase.expected)
+		}
+	}
+}
+
+func TestPaths(t *testing.T) {
+	selector := Paths("users.*.id", "meta.created_*")
+
+	for _, testCase := range []struct {
+		valuePath []string
+		isMember  bool
+		expected  bool
+	}{
+		{[]string{"users", "0", "id"}, true, true},
+		{[]string{"users", "owner", "id"}, true, true},
+		{[]string{"meta", "created_at"}, true, true},
+		{[]string{"users", "0", "userId"}, true, false},
+		{[]string{"users", "0", "a", "id"}, true, false},
+		{[]string{"id"}, true, false},
+		{[]string{"meta", "createdAt"}, true, false},
+	} {
+		if selected := selector(testCase.valuePath, testCase.isMember); testCase.expected != selected {
+			t.Errorf("Selection of %v was `%t`, when expected to be `%t`", testCase.valuePath, selected, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package transform
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// TimeFormat is a structure defining a format of timestamps in JSON data,
+// either as strings of a time layout or as numbers of a Unix time unit.
+type TimeFormat struct {
+	layout string
+	unit   time.Duration
+}
+
+var (
+	// RFC3339 is the TimeFormat of RFC 3339 strings, with any fractional
+	// seconds, as `time.Time` values are marshaled.
+	RFC3339 = TimeLayout(time.RFC3339Nano)
+
+	// UnixSeconds is the TimeFormat of numbers of seconds since the Unix
+	// epoch, with any fractional seconds as decimals.
+	UnixSeconds = TimeFormat{unit: time.Second}
+
+	// UnixMilliseconds is the TimeFormat of numbers of milliseconds since the
+	// Unix epoch, with any fractional milliseconds as decimals.
+	UnixMilliseconds = TimeFormat{unit: time.Millisecond}
+)
+
+// TimeLayout takes a layout, as defined by the `time` package, and returns the
+// TimeFormat of strings of the layout.
+//
+// The layout must not be empty, and TimeLayout panics if it is, rather than
+// returning a TimeFormat that no timestamp could be converted to or from.
+func TimeLayout(layout string) TimeFormat {
+	if "" == layout {
+		panic("transform: TimeLayout layout is empty")
+	}
+
+	return TimeFormat{layout: layout}
+}
+
+// Times takes a Selector and a pair of `TimeFormat`s and returns a Transformer
+// that converts the selected JSON timestamp values between the formats.
+//
+// The native format is the format of the timestamps as they're marshaled from
+// and unmarshaled into Go values, such as RFC3339 for `time.Time` values. The
+// wire format is the format of the timestamps in the transformed data. For the
+// "Marshal" direction, timestamps are converted from the native format to the
+// wire format, and for the "Unmarshal" direction, from the wire format to the
+// native format.
+//
+// Unix timestamps are read from either JSON numbers or strings containing
+// them, and are converted to RFC 3339 in UTC. Values that aren't timestamps of
+// the format being converted from, such as null, are kept as-is. When a
+// selected value is an array, its elements are converted instead.
+//
+// Both formats must be made by TimeLayout or be one of the predefined formats,
+// and Times panics if either is the zero TimeFormat, rather than returning a
+// Transformer that would fail to convert any timestamp.
+func Times(selector Selector, native, wire TimeFormat) Transformer {
+	if native.isZero() || wire.isZero() {
+		panic("transform: Times format is the zero TimeFormat")
+	}
+
+	return func(data []byte, direction Direction) []byte {
+		from, to := native, wire
+
+		if Unmarshal == direction {
+			from, to = wire, native
+		}
+
+		convert := func(node *jsontree.Node, value []byte) []byte {
+			if t, ok := from.parse(node, value); ok {
+				return to.format(t)
+			}
+
+			return value
+		}
+
+		return rewriteValues(data, func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			if !selector(valuePath, isMember) {
+				return nil, false
+			}
+
+			return rewriteElements(node, value, convert), true
+		}, nil)
+	}
+}
+
+// isZero returns whether the format is the zero TimeFormat, which is neither
+// a layout nor a unit.
+func (f TimeFormat) isZero() bool {
+	return "" == f.layout && 0 == f.unit
+}
+
+// parse takes the node of a JSON value and the value's bytes and returns the
+// time of the value in the format, and whether the value is in the format.
+func (f TimeFormat) parse(node *jsontree.Node, value []byte) (time.Time, bool) {
+	content := string(value)
+
+	if jsontree.String == node.Kind {
+		if nil != json.Unmarshal(value, &content) {
+			return time.Time{}, false
+		}
+	} else if jsontree.Number != node.Kind || "" != f.layout {
+		return time.Time{}, false
+	}
+
+	if "" != f.layout {
+		t, err := time.Parse(f.layout, content)
+
+		return t, nil == err
+	}
+
+	// Parse the number exactly, as Unix milliseconds beyond 2^53 nanoseconds
+	// lose precision as a float64, but not before rejecting numbers far out of
+	// the range of times
+	if magnitude, err := strconv.ParseFloat(content, 64); !isNumber(content) || nil != err || 1e20 < math.Abs(magnitude) {
+		return time.Time{}, false
+	}
+
+	units, _ := new(big.Rat).SetString(content)
+	nanoseconds := units.Mul(units, new(big.Rat).SetInt64(int64(f.unit)))
+	seconds, remainder := new(big.Int).DivMod(
+		new(big.Int).Quo(nanoseconds.Num(), nanoseconds.Denom()),
+		big.NewInt(int64(time.Second)),
+		new(big.Int),
+	)
+
+	if !seconds.IsInt64() {
+		return time.Time{}, false
+	}
+
+	return time.Unix(seconds.Int64(), remainder.Int64()).UTC(), true
+}
+
+// format takes a time and returns the JSON value of the time in the format.
+func (f TimeFormat) format(t time.Time) []byte {
+	if "" != f.layout {
+		return appendCanonicalString(nil, t.Format(f.layout))
+	}
+
+	// Count whole units from whole seconds, so that times beyond the range of
+	// `time.Time.UnixNano` are kept
+	perSecond := int64(time.Second / f.unit)
+	units := t.Unix()*perSecond + int64(t.Nanosecond())/int64(f.unit)
+	fraction := int64(t.Nanosecond()) % int64(f.unit)
+
+	if 0 == fraction {
+		return strconv.AppendInt(nil, units, 10)
+	}
+
+	sign := ""
+
+	// Negative times with a fraction are a whole unit closer to zero
+	if 0 > units {
+		sign, units, fraction = "-", -(units + 1), int64(f.unit)-fraction
+	}
+
+	decimals := strings.TrimRight(strconv.FormatInt(int64(f.unit)+fraction, 10)[1:], "0")
+
+	return []byte(sign + strconv.FormatInt(units, 10) + "." + decimals)
+}
@@ -0,0 +1,72 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestTimes(t *testing.T) {
+	for _, testCase := range []struct {
+		native, wire TimeFormat
+		nativeJSON   string
+		wireJSON     string
+	}{
+		{RFC3339, UnixSeconds, `{"created_at":"2023-11-14T22:13:20Z"}`, `{"created_at":1700000000}`},
+		{RFC3339, UnixSeconds, `{"created_at":"2023-11-14T22:13:20.25Z"}`, `{"created_at":1700000000.25}`},
+		{RFC3339, UnixMilliseconds, `{"created_at":"2023-11-14T22:13:20.123Z"}`, `{"created_at":1700000000123}`},
+		{RFC3339, UnixMilliseconds, `{"created_at":"2023-11-14T22:13:20.123456789Z"}`, `{"created_at":1700000000123.456789}`},
+		{RFC3339, UnixSeconds, `{"created_at":"1969-12-31T23:59:58.5Z"}`, `{"created_at":-1.5}`},
+		{RFC3339, TimeLayout("2006-01-02 15:04:05"), `{"created_at":"2023-11-14T22:13:20Z"}`, `{"created_at":"2023-11-14 22:13:20"}`},
+		{UnixMilliseconds, RFC3339, `{"created_at":1700000000123}`, `{"created_at":"2023-11-14T22:13:20.123Z"}`},
+		{RFC3339, UnixSeconds, `{"created_at":null,"updated_at":"soon"}`, `{"created_at":null,"updated_at":"soon"}`},
+		{RFC3339, UnixSeconds, `{"deleted_at":["2023-11-14T22:13:20Z"],"name":"2023-11-14T22:13:20Z"}`, `{"deleted_at":[1700000000],"name":"2023-11-14T22:13:20Z"}`},
+	} {
+		trans := Times(Keys("*_at"), testCase.native, testCase.wire)
+
+		if output := trans([]byte(testCase.nativeJSON), Marshal); testCase.wireJSON != string(output) {
+			t.Errorf("Marshal output of %s doesn't match expected %s", output, testCase.wireJSON)
+		}
+
+		if output := trans([]byte(testCase.wireJSON), Unmarshal); testCase.nativeJSON != string(output) {
+			t.Errorf("Unmarshal output of %s doesn't match expected %s", output, testCase.nativeJSON)
+		}
+	}
+}
+
+func TestTimesUnmarshal(t *testing.T) {
+	trans := Times(Paths("event.time"), RFC3339, UnixMilliseconds)
+
+	for _, testCase := range []struct {
+		inputJSON      string
+		expectedOutput string
+	}{
+		{`{"event":{"time":"1700000000123"}}`, `{"event":{"time":"2023-11-14T22:13:20.123Z"}}`},
+		{`{"event":{"time":1.700000000123e12}}`, `{"event":{"time":"2023-11-14T22:13:20.123Z"}}`},
+		{`{"event":{"time":"2023-11-14T22:13:20Z"}}`, `{"event":{"time":"2023-11-14T22:13:20Z"}}`},
+		{`{"event":{"time":1e400}}`, `{"event":{"time":1e400}}`},
+		{`{"time":1700000000123}`, `{"time":1700000000123}`},
+		{`{"event":{"time":" 1700000000123"}}`, `{"event":{"time":" 1700000000123"}}`},
+		{`{"event":{"time":"0x10"}}`, `{"event":{"time":"0x10"}}`},
+	} {
+		if output := trans([]byte(testCase.inputJSON), Unmarshal); testCase.expectedOutput != string(output) {
+			t.Errorf("Output of %s doesn't match expected %s", output, testCase.expectedOutput)
+		}
+	}
+}
+
+func TestTimes_AssertZeroFormatIsRejected(t *testing.T) {
+	for name, construct := range map[string]func(){
+		"TimeLayout with an empty layout": func() { TimeLayout("") },
+		"Times with a zero native format": func() { Times(Keys("time"), TimeFormat{}, UnixSeconds) },
+		"Times with a zero wire format":   func() { Times(Keys("time"), RFC3339, TimeFormat{}) },
+	} {
+		func() {
+			defer func() {
+				if recovered := recover(); nil == recovered {
+					t.Errorf("%s didn't panic", name)
+				}
+			}()
+
+			construct()
+		}()
+	}
+}
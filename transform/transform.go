@@ -6,6 +6,7 @@ package transform
 
 import (
 	"bytes"
+	"context"
 	"regexp"
 	"unicode"
 )
@@ -17,6 +18,14 @@ type Direction bool
 // given direction, to a result of bytes.
 type Transformer func([]byte, Direction) []byte
 
+// ContextTransformer defines a function that transforms a source bytes of
+// data, in a given direction and within a given context, to a result of bytes.
+//
+// Unlike a Transformer, a ContextTransformer is able to read request-scoped
+// values (such as a tenant, a caller's role, or a set of requested fields)
+// from the context of the operation that it's running in.
+type ContextTransformer func(context.Context, []byte, Direction) []byte
+
 const (
 	// Marshal defines the direction of marshaling or encoding.
 	Marshal Direction = false
@@ -30,6 +39,7 @@ var (
 	camelCaseWordBarrierRegex         = regexp.MustCompile(`([^A-Z])([A-Z])`)
 	snakeCaseWordBarrierRegex         = regexp.MustCompile(`(?:[^_])_(.)`)
 	repeatedUpperCaseWordBarrierRegex = regexp.MustCompile(`(?:[A-Z])([A-Z]+?)(?:[^A-Z]|$)`)
+	upperCaseRunWordBarrierRegex      = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
 )
 
 // String satisfies the fmt.Stringer interface to provide a human-readable name
@@ -90,6 +100,23 @@ func ReverseDirection(transform Transformer) Transformer {
 	}
 }
 
+// WithContext takes a context and a ContextTransformer and returns a new
+// Transformer that executes the given ContextTransformer within the given
+// context.
+func WithContext(ctx context.Context, transform ContextTransformer) Transformer {
+	return func(data []byte, direction Direction) []byte {
+		return transform(ctx, data, direction)
+	}
+}
+
+// IgnoreContext takes a Transformer and returns a new ContextTransformer that
+// executes the given Transformer, ignoring the context that it's run within.
+func IgnoreContext(transform Transformer) ContextTransformer {
+	return func(ctx context.Context, data []byte, direction Direction) []byte {
+		return transform(data, direction)
+	}
+}
+
 // ConventionalKeys returns a Transformer that converts every JSON "key" (or
 // JSON object "name") in the transformed data set, depending on the
 // transformation direction, based on common JSON data style conventions.
@@ -105,12 +132,7 @@ func ConventionalKeys() Transformer {
 					return snakeCaseToCamelCaseWordBarrier(key)
 				}
 
-				return bytes.ToLower(
-					camelCaseWordBarrierRegex.ReplaceAll(
-						key,
-						[]byte("${1}_${2}"),
-					),
-				)
+				return camelCaseToSnakeCase(key)
 			},
 		)
 	}
@@ -202,6 +224,13 @@ func replaceKeys(data []byte, replaceFunc func(key []byte) []byte) []byte {
 	)
 }
 
+// camelCaseToSnakeCase takes a source JSON data and converts the `camelCase`
+// style words within it to lower-cased `snake_case` style words, based on a
+// "word-barrier" regular expression, and returns the resulting bytes.
+func camelCaseToSnakeCase(data []byte) []byte {
+	return bytes.ToLower(camelCaseWordBarrierRegex.ReplaceAll(data, []byte("${1}_${2}")))
+}
+
 // snakeCaseToCamelCaseWordBarrier takes a source JSON data and replaces all
 // `snake_case` style JSON keys with `camelCase` style JSON keys, based on a
 // "word-barrier" regular expression, and returns the resulting bytes.
@@ -2,6 +2,7 @@ package transform
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"go/parser"
@@ -151,6 +152,55 @@ func TestReverseDirection(t *testing.T) {
 	}
 }
 
+func TestWithContext(t *testing.T) {
+	type contextKey struct{}
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "context value")
+
+	trans := WithContext(
+		ctx,
+		func(ctx context.Context, data []byte, direction Direction) []byte {
+			return []byte(fmt.Sprintf("%s: %s", direction, ctx.Value(contextKey{})))
+		},
+	)
+
+	for _, testCase := range []struct {
+		direction      Direction
+		expectedOutput []byte
+	}{
+		{Marshal, []byte("Marshal: context value")},
+		{Unmarshal, []byte("Unmarshal: context value")},
+	} {
+		if output := trans([]byte(""), testCase.direction); !bytes.Equal(output, testCase.expectedOutput) {
+			t.Errorf("%s output of %q doesn't match expected %q", testCase.direction, output, testCase.expectedOutput)
+		}
+	}
+}
+
+func TestIgnoreContext(t *testing.T) {
+	trans := IgnoreContext(
+		func(data []byte, direction Direction) []byte {
+			return []byte(direction.String())
+		},
+	)
+
+	for _, testCase := range []struct {
+		ctx       context.Context
+		direction Direction
+	}{
+		{context.Background(), Marshal},
+		{context.Background(), Unmarshal},
+		{context.TODO(), Marshal},
+		{context.TODO(), Unmarshal},
+	} {
+		expectedDirectionBytes := []byte(testCase.direction.String())
+
+		if output := trans(testCase.ctx, []byte(""), testCase.direction); !bytes.Equal(output, expectedDirectionBytes) {
+			t.Errorf("%s output of %q doesn't match expected %q", testCase.direction, output, expectedDirectionBytes)
+		}
+	}
+}
+
 func TestConventionalKeys(t *testing.T) {
 	const snakeCaseJSON = `
 	{
@@ -0,0 +1,167 @@
+package transform
+
+import (
+	"bytes"
+	"path"
+	"strconv"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+// rewriteFunc defines a function that takes the path of a JSON value, by the
+// keys of objects and the indexes of arrays, whether the value is the value of
+// an object member (rather than an array element or the top-level value), the
+// value's node, and the value's bytes, and returns the value's replacement and
+// whether the value is replaced. A nil replacement removes the value from its
+// enclosing object or array.
+type rewriteFunc func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool)
+
+// rewriteValues takes JSON data and a pair of rewriteFuncs and returns the
+// data with every value replaced as returned by the functions, either of which
+// may be nil.
+//
+// The before function is called with each value before the values within it
+// are walked, and values that it replaces aren't walked. The after function is
+// called with each value that isn't replaced, once the values within it are
+// rewritten, with the rewritten value's bytes. The formatting of the data is
+// kept wherever possible.
+//
+// Data that isn't valid JSON is returned as-is.
+func rewriteValues(data []byte, before, after rewriteFunc) []byte {
+	root, err := jsontree.Parse(data)
+
+	if nil != err {
+		return data
+	}
+
+	rewritten, _ := rewriteNode(nil, false, root, data, before, after)
+
+	// A removed top-level value leaves a null
+	if nil == rewritten {
+		rewritten = []byte("null")
+	}
+
+	// Keep any whitespace surrounding the top-level value
+	return append(append(append([]byte(nil), data[:root.Start]...), rewritten...), data[root.End:]...)
+}
+
+// rewriteNode takes the path of a JSON value, whether the value is the value of
+// an object member, the value's node, the data that the node was parsed from,
+// and a pair of rewriteFuncs, and returns the rewritten value and whether it
+// changed.
+func rewriteNode(valuePath []string, isMember bool, node *jsontree.Node, data []byte, before, after rewriteFunc) ([]byte, bool) {
+	if nil != before {
+		if replacement, isReplaced := before(valuePath, isMember, node, node.Raw(data)); isReplaced {
+			return replacement, true
+		}
+	}
+
+	rewritten, isChanged := rewriteChildren(valuePath, node, data, before, after)
+
+	if nil != after {
+		if replacement, isReplaced := after(valuePath, isMember, node, rewritten); isReplaced {
+			return replacement, true
+		}
+	}
+
+	return rewritten, isChanged
+}
+
+// rewriteChildren takes the path of a JSON value, the value's node, the data
+// that the node was parsed from, and a pair of rewriteFuncs, and returns the
+// value with the values within it rewritten, and whether it changed.
+func rewriteChildren(valuePath []string, node *jsontree.Node, data []byte, before, after rewriteFunc) ([]byte, bool) {
+	var children []*jsontree.Node
+	var starts []int
+
+	switch node.Kind {
+	case jsontree.Object:
+		for _, member := range node.Members {
+			children = append(children, member.Value)
+			starts = append(starts, member.KeyStart)
+		}
+	case jsontree.Array:
+		for _, element := range node.Elements {
+			children = append(children, element)
+			starts = append(starts, element.Start)
+		}
+	}
+
+	values := make([][]byte, len(children))
+	isChanged := false
+
+	for i, child := range children {
+		segment := strconv.Itoa(i)
+
+		if jsontree.Object == node.Kind {
+			segment = node.Members[i].Key
+		}
+
+		var childChanged bool
+		childPath := append(valuePath[:len(valuePath):len(valuePath)], segment)
+		values[i], childChanged = rewriteNode(childPath, jsontree.Object == node.Kind, child, data, before, after)
+		isChanged = isChanged || childChanged
+	}
+
+	if !isChanged {
+		return node.Raw(data), false
+	}
+
+	// Rebuild the value from its kept children, using the separators that
+	// preceded each child in the original data
+	rewritten := []byte{data[node.Start]}
+	kept := 0
+
+	for i, child := range children {
+		if nil == values[i] {
+			continue
+		}
+
+		if 0 == kept {
+			rewritten = append(rewritten, data[node.Start+1:starts[0]]...)
+		} else {
+			rewritten = append(rewritten, data[children[i-1].End:starts[i]]...)
+		}
+
+		rewritten = append(rewritten, data[starts[i]:child.Start]...)
+		rewritten = append(rewritten, values[i]...)
+		kept++
+	}
+
+	if 0 == kept {
+		return append(rewritten, data[node.End-1]), true
+	}
+
+	return append(rewritten, data[children[len(children)-1].End:node.End]...), true
+}
+
+// normalizeKey takes a JSON object key and returns the key in a normal form,
+// lower-cased with its words separated by underscores, so that keys in
+// different case conventions may be compared.
+//
+// The words of the key are found the same as they are for the keys converted
+// by ConventionalKeys, except that a run of upper-case letters is also treated
+// as a word of its own, the same as CamelCaseKeys treats it, so that the key
+// "APIToken" is normalized to "api_token".
+func normalizeKey(key string) string {
+	normalized := bytes.Replace([]byte(key), []byte("-"), []byte("_"), -1)
+	normalized = camelCaseWordBarrierRegex.ReplaceAll(normalized, []byte("${1}_${2}"))
+	normalized = upperCaseRunWordBarrierRegex.ReplaceAll(normalized, []byte("${1}_${2}"))
+
+	return string(bytes.ToLower(normalized))
+}
+
+// matchKey takes a list of key patterns and a JSON object key and returns
+// whether the key matches any of the patterns, regardless of the case
+// conventions of either. Patterns may contain the wildcards of `path.Match`.
+func matchKey(patterns []string, key string) bool {
+	normalized := normalizeKey(key)
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(normalizeKey(pattern), normalized); matched {
+			return true
+		}
+	}
+
+	return false
+}
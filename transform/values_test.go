@@ -0,0 +1,110 @@
+package transform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Rican7/conjson/internal/jsontree"
+)
+
+func TestRewriteValues(t *testing.T) {
+	const inputJSON = `
+	{
+		"keep": 1,
+		"remove": 2,
+		"nested": [1, 2, 3],
+		"last": {"remove": true}
+	}
+	`
+
+	const expectedJSON = `
+	{
+		"keep": 1,
+		"nested": [1, "two", 3],
+		"last": {}
+	}
+	`
+
+	output := rewriteValues([]byte(inputJSON), func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+		switch {
+		case isMember && "remove" == valuePath[len(valuePath)-1]:
+			return nil, true
+		case !isMember && 2 == len(valuePath) && "1" == valuePath[1]:
+			return []byte(`"two"`), true
+		}
+
+		return nil, false
+	}, nil)
+
+	if expectedJSON != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expectedJSON)
+	}
+
+	for _, testCase := range []struct {
+		input          string
+		expectedOutput string
+	}{
+		{`[1, 2]`, `[2]`},
+		{`[2, 1]`, `[2]`},
+		{`{"a": 1, "b": 2, "c": 1}`, `{"b": 2}`},
+		{`1`, `null`},
+		{`not JSON`, `not JSON`},
+	} {
+		output := rewriteValues([]byte(testCase.input), func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			return nil, "1" == string(value)
+		}, nil)
+
+		if testCase.expectedOutput != string(output) {
+			t.Errorf("Output %s doesn't match expected %s", output, testCase.expectedOutput)
+		}
+	}
+}
+
+func TestRewriteValues_AssertAfterSeesRewrittenValues(t *testing.T) {
+	var afterValues []string
+
+	output := rewriteValues(
+		[]byte(`{"a":[1,{"b":1}]}`),
+		func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			return []byte("2"), "1" == string(value)
+		},
+		func(valuePath []string, isMember bool, node *jsontree.Node, value []byte) ([]byte, bool) {
+			afterValues = append(afterValues, string(value))
+
+			return nil, false
+		},
+	)
+
+	if expected := `{"a":[2,{"b":2}]}`; expected != string(output) {
+		t.Errorf("Output %s doesn't match expected %s", output, expected)
+	}
+
+	if expected := []string{`{"b":2}`, `[2,{"b":2}]`, `{"a":[2,{"b":2}]}`}; fmt.Sprint(expected) != fmt.Sprint(afterValues) {
+		t.Errorf("afterValues were `%v`, when expected to be `%v`", afterValues, expected)
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	for _, testCase := range []struct {
+		patterns []string
+		key      string
+		expected bool
+	}{
+		{[]string{"password"}, "password", true},
+		{[]string{"password"}, "Password", true},
+		{[]string{"password"}, "passwords", false},
+		{[]string{"*_token"}, "api_token", true},
+		{[]string{"*_token"}, "apiToken", true},
+		{[]string{"*_token"}, "api-token", true},
+		{[]string{"*_token"}, "token", false},
+		{[]string{"apiToken"}, "api_token", true},
+		{[]string{"ssn", "*_token"}, "SSN", true},
+		{[]string{"*_token"}, "APIToken", true},
+		{[]string{"user_api_token"}, "userAPIToken", true},
+		{[]string{"image_url"}, "ImageURL", true},
+	} {
+		if matched := matchKey(testCase.patterns, testCase.key); testCase.expected != matched {
+			t.Errorf("matchKey(%v, %q) was `%t`, when expected to be `%t`", testCase.patterns, testCase.key, matched, testCase.expected)
+		}
+	}
+}